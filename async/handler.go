@@ -0,0 +1,182 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+/*
+Package async provides a handler for handling records in a background goroutine.
+
+It offloads Handle of the wrapped handler from the calling goroutine, e.g. the request goroutine,
+by enqueuing records into a bounded queue which is consumed by a background worker.
+If the queue is full, it drops the record unless another policy is set by WithFullPolicy.
+
+The returned close function must be called before the program exits to flush the queued records.
+
+	handler, closeHandler := async.New(gcp.New())
+	defer closeHandler()
+*/
+package async
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+const defaultQueueSize = 1024
+
+// FullPolicy is the policy for handling records if the queue is full.
+type FullPolicy int
+
+const (
+	// PolicyDrop drops the new record if the queue is full.
+	PolicyDrop FullPolicy = iota
+	// PolicyBlock blocks Handle until there is room in the queue if the queue is full,
+	// which protects records from being dropped but might stall the calling goroutine.
+	PolicyBlock
+	// PolicyDropOldest drops the oldest record in the queue to make room for the new record
+	// if the queue is full, so the newest records survive.
+	PolicyDropOldest
+)
+
+// Handler handles records with the wrapped handler in a background goroutine.
+//
+// To create a new Handler, call [New].
+type Handler struct {
+	handler slog.Handler
+	*worker
+}
+
+// New creates a new Handler with the given Option(s), and a function which closes the Handler.
+// The close function waits until all queued records have been handled.
+// Records handled after close are dropped.
+//
+// Errors returned by the wrapped handler are discarded since the records are handled asynchronously.
+func New(handler slog.Handler, opts ...Option) (Handler, func()) {
+	if handler == nil {
+		panic("cannot create Handler with nil handler")
+	}
+
+	option := &options{}
+	for _, opt := range opts {
+		opt(option)
+	}
+	if option.queueSize <= 0 {
+		option.queueSize = defaultQueueSize
+	}
+
+	worker := &worker{
+		queue:      make(chan entry, option.queueSize),
+		done:       make(chan struct{}),
+		fullPolicy: option.fullPolicy,
+	}
+	go worker.run()
+
+	return Handler{handler: handler, worker: worker}, worker.close
+}
+
+func (h Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h Handler) Handle(ctx context.Context, record slog.Record) error {
+	// The context might be canceled once the calling function returns,
+	// and the record might be reused by the caller, so they are detached before enqueuing.
+	h.enqueue(entry{
+		ctx:     context.WithoutCancel(ctx),
+		handler: h.handler,
+		record:  record.Clone(),
+	})
+
+	return nil
+}
+
+func (h Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h.handler = h.handler.WithAttrs(attrs)
+
+	return h
+}
+
+func (h Handler) WithGroup(name string) slog.Handler {
+	h.handler = h.handler.WithGroup(name)
+
+	return h
+}
+
+// Dropped returns the number of records dropped because the queue is full or the handler is closed,
+// including the oldest records evicted by PolicyDropOldest.
+func (h Handler) Dropped() uint64 {
+	return h.dropped.Load()
+}
+
+type (
+	worker struct {
+		queue      chan entry
+		done       chan struct{}
+		fullPolicy FullPolicy
+
+		mu      sync.RWMutex
+		closed  bool
+		dropped atomic.Uint64
+	}
+	entry struct {
+		ctx     context.Context //nolint:containedctx // It's for handling the record asynchronously.
+		handler slog.Handler
+		record  slog.Record
+	}
+)
+
+func (w *worker) run() {
+	defer close(w.done)
+
+	for entry := range w.queue {
+		_ = entry.handler.Handle(entry.ctx, entry.record)
+	}
+}
+
+func (w *worker) enqueue(entry entry) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		w.dropped.Add(1)
+
+		return
+	}
+
+	switch w.fullPolicy {
+	case PolicyBlock:
+		w.queue <- entry
+	case PolicyDropOldest:
+		for {
+			select {
+			case w.queue <- entry:
+				return
+			default:
+			}
+
+			// Evict the oldest record, which might be taken by the worker concurrently.
+			select {
+			case <-w.queue:
+				w.dropped.Add(1)
+			default:
+			}
+		}
+	default:
+		select {
+		case w.queue <- entry:
+		default:
+			w.dropped.Add(1)
+		}
+	}
+}
+
+func (w *worker) close() {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mu.Unlock()
+
+	<-w.done
+}
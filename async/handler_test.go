@@ -0,0 +1,156 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+package async_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/nil-go/sloth/async"
+	"github.com/nil-go/sloth/internal/assert"
+)
+
+func TestNew_panic(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		assert.Equal(t, "cannot create Handler with nil handler", recover().(string))
+	}()
+
+	async.New(nil)
+	t.Fail()
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	handler, closeHandler := async.New(textHandler(buf), async.WithFullPolicy(async.PolicyBlock))
+	logger := slog.New(handler).WithGroup("g").With("a", "A")
+	ctx, cancel := context.WithCancel(context.Background())
+	logger.InfoContext(ctx, "info")
+	cancel()
+	logger.InfoContext(ctx, "canceled")
+	closeHandler()
+	logger.Info("closed")
+
+	assert.Equal(t, `level=INFO msg=info g.a=A
+level=INFO msg=canceled g.a=A
+`, buf.String())
+	assert.Equal(t, uint64(1), handler.Dropped())
+}
+
+func TestHandler_flushOnClose(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	handler, closeHandler := async.New(textHandler(buf), async.WithQueueSize(100))
+	logger := slog.New(handler)
+	for i := range 100 {
+		logger.Info("msg " + strconv.Itoa(i))
+	}
+	closeHandler()
+	closeHandler() // It's safe to close multiple times.
+
+	assert.Equal(t, 100, bytes.Count(buf.Bytes(), []byte("\n")))
+	assert.Equal(t, uint64(0), handler.Dropped())
+}
+
+func TestHandler_fullPolicy(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		policy      async.FullPolicy
+		expected    []string
+		dropped     uint64
+	}{
+		{
+			description: "drop",
+			policy:      async.PolicyDrop,
+			expected:    []string{"msg 0", "msg 1", "msg 2"},
+			dropped:     2,
+		},
+		{
+			description: "drop oldest",
+			policy:      async.PolicyDropOldest,
+			expected:    []string{"msg 0", "msg 3", "msg 4"},
+			dropped:     2,
+		},
+		{
+			description: "block",
+			policy:      async.PolicyBlock,
+			expected:    []string{"msg 0", "msg 1", "msg 2", "msg 3", "msg 4"},
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.description, func(t *testing.T) {
+			t.Parallel()
+
+			blocking := &blockingHandler{release: make(chan struct{}), started: make(chan struct{})}
+			handler, closeHandler := async.New(blocking, async.WithQueueSize(2), async.WithFullPolicy(testcase.policy))
+			logger := slog.New(handler)
+
+			// The first record is taken by the worker, which is blocked until released.
+			logger.Info("msg 0")
+			<-blocking.started
+			logged := make(chan struct{})
+			go func() {
+				defer close(logged)
+
+				for i := 1; i < 5; i++ {
+					logger.Info("msg " + strconv.Itoa(i))
+				}
+			}()
+			if testcase.policy != async.PolicyBlock {
+				// Other policies never block, so the queue is saturated before releasing the worker.
+				<-logged
+			}
+			close(blocking.release)
+			<-logged
+			closeHandler()
+
+			assert.Equal(t, testcase.expected, blocking.messages)
+			assert.Equal(t, testcase.dropped, handler.Dropped())
+		})
+	}
+}
+
+func textHandler(buf *bytes.Buffer) slog.Handler {
+	return slog.NewTextHandler(buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if len(groups) == 0 && attr.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+
+			return attr
+		},
+	})
+}
+
+type blockingHandler struct {
+	once     sync.Once
+	started  chan struct{}
+	release  chan struct{}
+	messages []string
+}
+
+func (*blockingHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *blockingHandler) Handle(_ context.Context, record slog.Record) error {
+	h.once.Do(func() { close(h.started) })
+	<-h.release
+	h.messages = append(h.messages, record.Message)
+
+	return nil
+}
+
+func (h *blockingHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h *blockingHandler) WithGroup(string) slog.Handler { return h }
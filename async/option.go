@@ -0,0 +1,32 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+package async
+
+// WithQueueSize provides the maximum number of records waiting in the queue for the background worker.
+//
+// If the size is <= 0, the handler assumes 1024.
+func WithQueueSize(size int) Option {
+	return func(options *options) {
+		options.queueSize = size
+	}
+}
+
+// WithFullPolicy provides the policy for handling records if the queue is full,
+// e.g. PolicyBlock which protects records from being dropped but might stall the calling goroutine.
+//
+// By default, the handler assumes PolicyDrop.
+func WithFullPolicy(policy FullPolicy) Option {
+	return func(options *options) {
+		options.fullPolicy = policy
+	}
+}
+
+type (
+	// Option configures the Handler with specific options.
+	Option  func(*options)
+	options struct {
+		queueSize  int
+		fullPolicy FullPolicy
+	}
+)
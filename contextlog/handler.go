@@ -0,0 +1,78 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+/*
+Package contextlog provides a handler for adding attributes from the context to records.
+
+It prepends attributes extracted from the context by the given extractors to each record,
+e.g. request id, tenant and user id, so they are on every record without calling Logger.With manually.
+
+	handler := contextlog.New(handler, func(ctx context.Context) []slog.Attr {
+		if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+			return []slog.Attr{slog.String("request_id", id)}
+		}
+
+		return nil
+	})
+*/
+package contextlog
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Handler adds attributes extracted from the context to records.
+//
+// To create a new Handler, call [New].
+type Handler struct {
+	handler    slog.Handler
+	extractors []func(context.Context) []slog.Attr
+}
+
+// New creates a new Handler with the given extractors.
+// The attributes extracted are added before the attributes of the record in the order of extractors,
+// and they are in the groups of the handler like other attributes of the record.
+func New(handler slog.Handler, extractors ...func(ctx context.Context) []slog.Attr) Handler {
+	if handler == nil {
+		panic("cannot create Handler with nil handler")
+	}
+
+	return Handler{handler: handler, extractors: extractors}
+}
+
+func (h Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h Handler) Handle(ctx context.Context, record slog.Record) error {
+	var attrs []slog.Attr
+	for _, extractor := range h.extractors {
+		attrs = append(attrs, extractor(ctx)...)
+	}
+	if len(attrs) == 0 {
+		return h.handler.Handle(ctx, record)
+	}
+
+	newRecord := slog.NewRecord(record.Time, record.Level, record.Message, record.PC)
+	newRecord.AddAttrs(attrs...)
+	record.Attrs(func(attr slog.Attr) bool {
+		newRecord.AddAttrs(attr)
+
+		return true
+	})
+
+	return h.handler.Handle(ctx, newRecord)
+}
+
+func (h Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h.handler = h.handler.WithAttrs(attrs)
+
+	return h
+}
+
+func (h Handler) WithGroup(name string) slog.Handler {
+	h.handler = h.handler.WithGroup(name)
+
+	return h
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+package contextlog_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/nil-go/sloth/contextlog"
+	"github.com/nil-go/sloth/internal/assert"
+)
+
+func TestNew_panic(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		assert.Equal(t, "cannot create Handler with nil handler", recover().(string))
+	}()
+
+	contextlog.New(nil)
+	t.Fail()
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		ctx         context.Context //nolint:containedctx
+		handler     func(slog.Handler) slog.Handler
+		expected    string
+	}{
+		{
+			description: "no values in context",
+			ctx:         context.Background(),
+			expected: `level=INFO msg=msg a=A
+`,
+		},
+		{
+			description: "values in context",
+			ctx: context.WithValue(
+				context.WithValue(context.Background(), requestIDKey{}, "req-1"),
+				tenantKey{}, "tenant-1",
+			),
+			expected: `level=INFO msg=msg request_id=req-1 tenant=tenant-1 a=A
+`,
+		},
+		{
+			description: "with group",
+			ctx:         context.WithValue(context.Background(), requestIDKey{}, "req-1"),
+			handler: func(handler slog.Handler) slog.Handler {
+				return handler.WithAttrs([]slog.Attr{slog.String("b", "B")}).WithGroup("g")
+			},
+			expected: `level=INFO msg=msg b=B g.request_id=req-1 g.a=A
+`,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.description, func(t *testing.T) {
+			t.Parallel()
+
+			buf := &bytes.Buffer{}
+			var handler slog.Handler = contextlog.New(textHandler(buf),
+				extractor[requestIDKey]("request_id"),
+				extractor[tenantKey]("tenant"),
+			)
+			if testcase.handler != nil {
+				handler = testcase.handler(handler)
+			}
+			slog.New(handler).InfoContext(testcase.ctx, "msg", "a", "A")
+
+			assert.Equal(t, testcase.expected, buf.String())
+		})
+	}
+}
+
+type (
+	requestIDKey struct{}
+	tenantKey    struct{}
+)
+
+func extractor[K any](key string) func(context.Context) []slog.Attr {
+	return func(ctx context.Context) []slog.Attr {
+		var contextKey K
+		if value, ok := ctx.Value(contextKey).(string); ok {
+			return []slog.Attr{slog.String(key, value)}
+		}
+
+		return nil
+	}
+}
+
+func textHandler(buf *bytes.Buffer) slog.Handler {
+	return slog.NewTextHandler(buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if len(groups) == 0 && attr.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+
+			return attr
+		},
+	})
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+/*
+Package datadog provides a handler which formats records to match the [Datadog] log conventions,
+so logs could be parsed by Datadog without custom pipelines.
+
+It maps keys of records to Datadog reserved and standard attributes, e.g. `timestamp`, `status` and `message`.
+It also supports trace correlation with `dd.trace_id` and `dd.span_id`,
+and error details with `error.kind` and `error.stack` if enabled.
+
+[Datadog]: https://docs.datadoghq.com/logs/log_configuration/attributes_naming_convention/
+*/
+package datadog
+
+import (
+	"log/slog"
+	"os"
+	"strconv"
+
+	"github.com/nil-go/sloth/internal/jsonlog"
+)
+
+// Keys for [W3C Trace Context] attributes, which are mapped to Datadog attributes `dd.trace_id` and `dd.span_id`.
+//
+// [W3C Trace Context]: https://www.w3.org/TR/trace-context/#traceparent-header-field-values
+const (
+	// TraceKey is the key used by the [ID of the whole trace] forest and is used to uniquely
+	// identify a distributed trace through a system. It is represented as a 16-byte array,
+	// for example, 4bf92f3577b34da6a3ce929d0e0e4736.
+	//
+	// [ID of the whole trace]: https://www.w3.org/TR/trace-context/#trace-id
+	TraceKey = "trace_id"
+	// SpanKey is the key used by the [ID of this request] as known by the caller.
+	// It is represented as an 8-byte array, for example, 00f067aa0ba902b7.
+	//
+	// [ID of this request]: https://www.w3.org/TR/trace-context/#parent-id
+	SpanKey = "span_id"
+)
+
+// ErrorKey is the key of the attribute which carries the error of the record,
+// which is mapped to Datadog attribute `error.message`.
+const ErrorKey = "error"
+
+// New creates a new Handler with the given Option(s).
+// The handler formats records to match the [Datadog] log conventions.
+//
+// [Datadog]: https://docs.datadoghq.com/logs/log_configuration/attributes_naming_convention/
+func New(opts ...Option) slog.Handler {
+	option := &options{}
+	for _, opt := range opts {
+		opt(option)
+	}
+	if option.writer == nil {
+		option.writer = os.Stderr
+	}
+
+	var handler slog.Handler
+	handler = slog.NewJSONHandler(
+		option.writer,
+		&slog.HandlerOptions{
+			AddSource:   true,
+			Level:       option.level,
+			ReplaceAttr: jsonlog.ReplaceAttr(fields()),
+		},
+	)
+	if option.contextProvider != nil || option.errorStack {
+		if option.callers == nil {
+			option.callers = jsonlog.ErrorCallers
+		}
+
+		// Correlate logs with the trace and span.
+		//
+		// See: https://docs.datadoghq.com/tracing/other_telemetry/connect_logs_and_traces/
+		//
+		// Add error details with the stack trace.
+		//
+		// See: https://docs.datadoghq.com/logs/log_configuration/attributes_naming_convention/#errors
+		handler = jsonlog.Handler{
+			Handler:         handler,
+			ContextProvider: option.contextProvider,
+			TraceKey:        TraceKey,
+			SpanKey:         SpanKey,
+			ErrorStack:      option.errorStack,
+			Callers:         option.callers,
+			ErrorTypeKey:    "error.kind",
+			StackKey:        "error.stack",
+		}
+	}
+
+	return handler
+}
+
+// fields maps attributes to Datadog reserved and standard attributes.
+//
+// See: https://docs.datadoghq.com/logs/log_configuration/attributes_naming_convention/#reserved-attributes
+func fields() jsonlog.Fields {
+	return jsonlog.Fields{
+		Time:    "timestamp",
+		Level:   "status",
+		Message: "message",
+		// See: https://docs.datadoghq.com/logs/log_configuration/attributes_naming_convention/#source-code
+		Source: func(source *slog.Source) slog.Attr {
+			return slog.Group("logger",
+				slog.String("file_name", source.File),
+				slog.Int("line", source.Line),
+				slog.String("method_name", source.Function),
+			)
+		},
+		// Datadog correlates logs with traces by the lower 64 bits of the trace id in decimal.
+		//
+		// See: https://docs.datadoghq.com/tracing/other_telemetry/connect_logs_and_traces/opentelemetry/
+		TraceKey: TraceKey,
+		Trace:    "dd.trace_id",
+		SpanKey:  SpanKey,
+		Span:     "dd.span_id",
+		ID:       decimalID,
+		// See: https://docs.datadoghq.com/logs/log_configuration/attributes_naming_convention/#errors
+		ErrorKey: ErrorKey,
+		Error:    "error.message",
+	}
+}
+
+// decimalID converts the lower 64 bits of the hex id to decimal, and reports false if the id is invalid.
+func decimalID(id string) (string, bool) {
+	const maxLength = 16 // The hex length of 64 bits.
+	if len(id) > maxLength {
+		id = id[len(id)-maxLength:]
+	}
+	value, err := strconv.ParseUint(id, 16, 64)
+	if err != nil || value == 0 {
+		return "", false
+	}
+
+	return strconv.FormatUint(value, 10), true
+}
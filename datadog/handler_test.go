@@ -0,0 +1,34 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+package datadog_test
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/nil-go/sloth/datadog"
+	"github.com/nil-go/sloth/internal/jsonlog/jsonlogtest"
+)
+
+//nolint:lll
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	jsonlogtest.Run(t,
+		func(writer io.Writer) slog.Handler {
+			return datadog.New(
+				datadog.WithWriter(writer),
+				datadog.WithTrace(jsonlogtest.TraceContext),
+				datadog.WithErrorStack(true),
+			)
+		},
+		map[string]string{
+			"info":         `{"timestamp":"1970-01-01T00:01:40.000001Z","status":"info","logger":{"file_name":"/jsonlogtest.go","line":76,"method_name":"github.com/nil-go/sloth/internal/jsonlog/jsonlogtest.Run.func1"},"message":"info","dd.trace_id":"11803532876627986230","dd.span_id":"67667974448284343","g":{"a":"A"}}`,
+			"warn":         `{"timestamp":"1970-01-01T00:01:40.000001Z","status":"warn","logger":{"file_name":"/jsonlogtest.go","line":76,"method_name":"github.com/nil-go/sloth/internal/jsonlog/jsonlogtest.Run.func1"},"message":"warn","dd.trace_id":"11803532876627986230","dd.span_id":"67667974448284343"}`,
+			"error":        `{"timestamp":"1970-01-01T00:01:40.000001Z","status":"error","logger":{"file_name":"/jsonlogtest.go","line":76,"method_name":"github.com/nil-go/sloth/internal/jsonlog/jsonlogtest.Run.func1"},"message":"error","dd.trace_id":"11803532876627986230","dd.span_id":"67667974448284343","error.kind":"jsonlogtest.stackError","error.stack":"goroutine 1 [running]:\ngithub.com/nil-go/sloth/internal/jsonlog/jsonlogtest.stackError.Callers()\n\t/jsonlogtest.go:104","error.message":"an error"}`,
+			"custom level": `{"timestamp":"1970-01-01T00:01:40.000001Z","status":"info","logger":{"file_name":"/jsonlogtest.go","line":76,"method_name":"github.com/nil-go/sloth/internal/jsonlog/jsonlogtest.Run.func1"},"message":"custom level","dd.trace_id":"11803532876627986230","dd.span_id":"67667974448284343"}`,
+		},
+	)
+}
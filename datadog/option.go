@@ -0,0 +1,75 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+package datadog
+
+import (
+	"context"
+	"io"
+	"log/slog"
+)
+
+// WithLevel provides the minimum record level that will be logged.
+// The handler discards records with lower levels.
+//
+// If Level is nil, the handler assumes LevelInfo.
+func WithLevel(level slog.Leveler) Option {
+	return func(options *options) {
+		options.level = level
+	}
+}
+
+// WithWriter provides the writer to which the handler writes.
+//
+// If Writer is nil, the handler assumes os.Stderr.
+func WithWriter(writer io.Writer) Option {
+	return func(options *options) {
+		options.writer = writer
+	}
+}
+
+// WithTrace provides the [W3C Trace Context] from the context, which is added as `dd.trace_id` and `dd.span_id`
+// if the trace id is valid and the record does not have attribute TraceKey yet.
+//
+// If it is nil, the handler only maps trace attributes in the record, e.g. TraceKey.
+//
+// [W3C Trace Context]: https://www.w3.org/TR/trace-context/#traceparent-header-field-values
+func WithTrace(provider func(context.Context) (traceID [16]byte, spanID [8]byte, traceFlags byte)) Option {
+	return func(options *options) {
+		options.contextProvider = provider
+	}
+}
+
+// WithErrorStack adds `error.kind` of the first error in the record and `error.stack`
+// to records with slog.LevelError and above. The message of the error is logged as `error.message`
+// if it's the attribute with key ErrorKey.
+func WithErrorStack(errorStack bool) Option {
+	return func(options *options) {
+		options.errorStack = errorStack
+	}
+}
+
+// WithCallers provides a function to get callers on the calling goroutine's stack
+// while WithErrorStack has been called.
+// If the callers returns empty slice, the handler gets stack trace from the caller of the record.
+//
+// If Callers is nil, the handler checks method `Callers() []uintptr` on the error.
+func WithCallers(callers func(error) []uintptr) Option {
+	return func(options *options) {
+		options.callers = callers
+	}
+}
+
+type (
+	// Option configures the Handler with specific options.
+	Option  func(*options)
+	options struct {
+		writer io.Writer
+		level  slog.Leveler
+
+		contextProvider func(context.Context) (traceID [16]byte, spanID [8]byte, traceFlags byte)
+
+		errorStack bool
+		callers    func(error) []uintptr
+	}
+)
@@ -0,0 +1,172 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+/*
+Package dedup provides a handler for suppressing identical consecutive records.
+
+It compares the level, message and attributes of each record with the previous record
+of the same logger (the same attributes and groups), and suppresses the record
+if they are identical and handled within the given window since the first of them.
+The window is measured by the time records are handled rather than the time of records,
+so records with old timestamps, e.g. replayed records, are suppressed and flushed consistently.
+Once a different record is handled, or the window elapses,
+it emits the suppressed record with attribute `repeated` for the number of suppressed records.
+*/
+package dedup
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RepeatedKey is the key of the attribute which reports the number of identical records suppressed.
+const RepeatedKey = "repeated"
+
+// Handler suppresses identical consecutive records within the window.
+//
+// To create a new Handler, call [New].
+type Handler struct {
+	handler slog.Handler
+	window  time.Duration
+	scope   string
+
+	state *state
+}
+
+// New creates a new Handler that suppresses identical consecutive records within the window.
+//
+// If the window is <= 0, the handler assumes 1 second.
+func New(handler slog.Handler, window time.Duration) Handler {
+	if handler == nil {
+		panic("cannot create Handler with nil handler")
+	}
+	if window <= 0 {
+		window = time.Second
+	}
+
+	return Handler{handler: handler, window: window, state: &state{lasts: make(map[string]*entry)}}
+}
+
+func (h Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h Handler) Handle(ctx context.Context, record slog.Record) error {
+	// Use the same clock as the timer which flushes the summary.
+	now := time.Now()
+	key := h.key(record)
+
+	h.state.mu.Lock()
+	last := h.state.lasts[h.scope]
+	if last != nil && last.key == key && now.Sub(last.start) < h.window {
+		last.repeated++
+		last.record.Time = record.Time
+		last.ctx = context.WithoutCancel(ctx)
+		if last.timer == nil {
+			// Flush the summary once the window elapses, even if no more records are handled.
+			last.timer = time.AfterFunc(h.window-now.Sub(last.start), func() { h.flush(last) })
+		}
+		h.state.mu.Unlock()
+
+		return nil
+	}
+	h.state.lasts[h.scope] = &entry{key: key, start: now, record: record.Clone()}
+	var summary slog.Record
+	suppressed := last != nil && last.repeated > 0
+	if suppressed {
+		last.timer.Stop()
+		summary = last.summary()
+	}
+	h.state.mu.Unlock()
+
+	var err error
+	if suppressed {
+		err = h.handler.Handle(ctx, summary)
+	}
+
+	// Still handle the record even if the summary fails, so it's not lost.
+	return errors.Join(err, h.handler.Handle(ctx, record))
+}
+
+// flush emits the summary of the entry if it's still the last entry of the scope
+// and has suppressed records, so the count is not lost if no more records are handled.
+func (h Handler) flush(last *entry) {
+	h.state.mu.Lock()
+	if h.state.lasts[h.scope] != last || last.repeated == 0 {
+		h.state.mu.Unlock()
+
+		return
+	}
+	delete(h.state.lasts, h.scope)
+	summary := last.summary()
+	ctx := last.ctx
+	h.state.mu.Unlock()
+
+	// Here ignores the error for best effort since there is no caller to return it to.
+	_ = h.handler.Handle(ctx, summary)
+}
+
+func (h Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h.handler = h.handler.WithAttrs(attrs)
+	h.scope += slog.GroupValue(attrs...).String()
+
+	return h
+}
+
+func (h Handler) WithGroup(name string) slog.Handler {
+	// Empty group name is a no-op as required by slog.Handler.
+	if name == "" {
+		return h
+	}
+
+	h.handler = h.handler.WithGroup(name)
+	h.scope += name + "."
+
+	return h
+}
+
+func (h Handler) key(record slog.Record) string {
+	var builder strings.Builder
+	builder.WriteString(h.scope)
+	builder.WriteString(record.Level.String())
+	builder.WriteByte(' ')
+	builder.WriteString(record.Message)
+	record.Attrs(func(attr slog.Attr) bool {
+		builder.WriteByte(' ')
+		attr.Value = attr.Value.Resolve()
+		builder.WriteString(attr.String())
+
+		return true
+	})
+
+	return builder.String()
+}
+
+type (
+	// state keeps the last entry for each scope, which is the attributes and groups of the handler,
+	// so interleaved records from different loggers do not interfere each other.
+	state struct {
+		mu    sync.Mutex
+		lasts map[string]*entry
+	}
+	entry struct {
+		key      string
+		start    time.Time
+		repeated int
+		record   slog.Record
+		ctx      context.Context //nolint:containedctx
+		timer    *time.Timer
+	}
+)
+
+// summary returns the last suppressed record with the number of suppressed records.
+func (e *entry) summary() slog.Record {
+	record := e.record.Clone()
+	record.AddAttrs(slog.Int(RepeatedKey, e.repeated))
+
+	return record
+}
@@ -0,0 +1,198 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+package dedup_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nil-go/sloth/dedup"
+	"github.com/nil-go/sloth/internal/assert"
+)
+
+func TestNew_panic(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		assert.Equal(t, "cannot create Handler with nil handler", recover().(string))
+	}()
+
+	dedup.New(nil, time.Second)
+	t.Fail()
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	buf := &syncBuffer{}
+	handler := dedup.New(slog.NewTextHandler(buf, nil), 50*time.Millisecond)
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	handle := func(handler slog.Handler, offset time.Duration, msg string, args ...any) {
+		record := slog.NewRecord(start.Add(offset), slog.LevelError, msg, 0)
+		record.Add(args...)
+		assert.NoError(t, handler.Handle(ctx, record))
+	}
+
+	handle(handler, 0, "failed", "a", "A")
+	handle(handler, 100*time.Millisecond, "failed", "a", "A")
+	handle(handler, 200*time.Millisecond, "failed", "a", "A")
+	// Different attributes.
+	handle(handler, 300*time.Millisecond, "failed", "a", "B")
+	// Different handler.
+	handle(handler.WithGroup("g"), 400*time.Millisecond, "failed", "a", "B")
+	handle(handler.WithGroup("g"), 500*time.Millisecond, "failed", "a", "B")
+	// Window elapsed, and the summary is flushed.
+	time.Sleep(100 * time.Millisecond)
+	handle(handler.WithGroup("g"), 1500*time.Millisecond, "failed", "a", "B")
+	handle(handler, 1600*time.Millisecond, "succeeded")
+
+	assert.Equal(t, `time=2024-01-01T00:00:00.000Z level=ERROR msg=failed a=A
+time=2024-01-01T00:00:00.200Z level=ERROR msg=failed a=A repeated=2
+time=2024-01-01T00:00:00.300Z level=ERROR msg=failed a=B
+time=2024-01-01T00:00:00.400Z level=ERROR msg=failed g.a=B
+time=2024-01-01T00:00:00.500Z level=ERROR msg=failed g.a=B g.repeated=1
+time=2024-01-01T00:00:01.500Z level=ERROR msg=failed g.a=B
+time=2024-01-01T00:00:01.600Z level=ERROR msg=succeeded
+`, buf.String())
+}
+
+func TestHandler_flush(t *testing.T) {
+	t.Parallel()
+
+	buf := &syncBuffer{}
+	handler := dedup.New(slog.NewTextHandler(buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if len(groups) == 0 && attr.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+
+			return attr
+		},
+	}), 50*time.Millisecond)
+	logger := slog.New(handler)
+
+	for range 3 {
+		logger.Error("failed")
+	}
+	assert.Equal(t, "level=ERROR msg=failed\n", buf.String())
+
+	// The summary is flushed once the window elapses without more records.
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, "level=ERROR msg=failed\nlevel=ERROR msg=failed repeated=2\n", buf.String())
+}
+
+func TestHandler_interleaved(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	handler := dedup.New(slog.NewTextHandler(buf, nil), time.Second)
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	handleA, handleB := handler.WithAttrs([]slog.Attr{slog.String("logger", "a")}),
+		handler.WithAttrs([]slog.Attr{slog.String("logger", "b")})
+
+	// Interleaved records of different loggers are suppressed separately.
+	for i := range 3 {
+		for _, handler := range []slog.Handler{handleA, handleB} {
+			record := slog.NewRecord(start.Add(time.Duration(i)*100*time.Millisecond), slog.LevelError, "failed", 0)
+			assert.NoError(t, handler.Handle(ctx, record))
+		}
+	}
+	assert.NoError(t, handleA.Handle(ctx, slog.NewRecord(start.Add(time.Second), slog.LevelInfo, "done", 0)))
+	assert.NoError(t, handleB.Handle(ctx, slog.NewRecord(start.Add(time.Second), slog.LevelInfo, "done", 0)))
+
+	assert.Equal(t, `time=2024-01-01T00:00:00.000Z level=ERROR msg=failed logger=a
+time=2024-01-01T00:00:00.000Z level=ERROR msg=failed logger=b
+time=2024-01-01T00:00:00.200Z level=ERROR msg=failed logger=a repeated=2
+time=2024-01-01T00:00:01.000Z level=INFO msg=done logger=a
+time=2024-01-01T00:00:00.200Z level=ERROR msg=failed logger=b repeated=2
+time=2024-01-01T00:00:01.000Z level=INFO msg=done logger=b
+`, buf.String())
+}
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+func TestHandler_emptyGroup(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	handler := dedup.New(slog.NewTextHandler(buf, nil), time.Second)
+	record := slog.NewRecord(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), slog.LevelInfo, "msg", 0)
+	assert.NoError(t, handler.Handle(context.Background(), record))
+	assert.NoError(t, handler.WithGroup("").Handle(context.Background(), record))
+	// The different record emits the summary, which also stops the flush timer.
+	record = slog.NewRecord(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), slog.LevelInfo, "done", 0)
+	assert.NoError(t, handler.Handle(context.Background(), record))
+
+	// The record with empty group is identical, so it's suppressed.
+	assert.Equal(t, `time=2024-01-01T00:00:00.000Z level=INFO msg=msg
+time=2024-01-01T00:00:00.000Z level=INFO msg=msg repeated=1
+time=2024-01-01T00:00:00.000Z level=INFO msg=done
+`, buf.String())
+}
+
+func TestHandler_summaryError(t *testing.T) {
+	t.Parallel()
+
+	var handled []string
+	handler := dedup.New(failHandler{handled: &handled}, time.Second)
+	logger := slog.New(handler)
+
+	logger.Error("failed")
+	logger.Error("failed")
+	// The summary fails, but the different record is still handled.
+	err := handler.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelError, "done", 0))
+	assert.Equal(t, "summary failed", err.Error())
+
+	assert.Equal(t, []string{"failed", "failed", "done"}, handled)
+}
+
+// failHandler records messages of handled records, and fails for summaries.
+type failHandler struct {
+	handled *[]string
+}
+
+func (failHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h failHandler) Handle(_ context.Context, record slog.Record) error {
+	*h.handled = append(*h.handled, record.Message)
+
+	var err error
+	record.Attrs(func(attr slog.Attr) bool {
+		if attr.Key == dedup.RepeatedKey {
+			err = errors.New("summary failed")
+		}
+
+		return err == nil
+	})
+
+	return err
+}
+
+func (h failHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h failHandler) WithGroup(string) slog.Handler { return h }
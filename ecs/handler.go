@@ -0,0 +1,124 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+/*
+Package ecs provides a handler which formats records to match [Elastic Common Schema] (ECS),
+so logs could be shipped to Elasticsearch without ingest pipelines.
+
+It maps keys of records to ECS fields, e.g. `@timestamp`, `log.level` and `message`.
+It also supports trace correlation with `trace.id` and `span.id`,
+and error details with `error.type` and `error.stack_trace` if enabled.
+
+[Elastic Common Schema]: https://www.elastic.co/guide/en/ecs/current/index.html
+*/
+package ecs
+
+import (
+	"log/slog"
+	"os"
+
+	"github.com/nil-go/sloth/internal/jsonlog"
+)
+
+// Keys for [W3C Trace Context] attributes, which are mapped to ECS fields `trace.id` and `span.id`.
+//
+// [W3C Trace Context]: https://www.w3.org/TR/trace-context/#traceparent-header-field-values
+const (
+	// TraceKey is the key used by the [ID of the whole trace] forest and is used to uniquely
+	// identify a distributed trace through a system. It is represented as a 16-byte array,
+	// for example, 4bf92f3577b34da6a3ce929d0e0e4736.
+	//
+	// [ID of the whole trace]: https://www.w3.org/TR/trace-context/#trace-id
+	TraceKey = "trace_id"
+	// SpanKey is the key used by the [ID of this request] as known by the caller.
+	// It is represented as an 8-byte array, for example, 00f067aa0ba902b7.
+	//
+	// [ID of this request]: https://www.w3.org/TR/trace-context/#parent-id
+	SpanKey = "span_id"
+)
+
+// ErrorKey is the key of the attribute which carries the error of the record,
+// which is mapped to ECS field `error.message` since `error` is an object in ECS.
+const ErrorKey = "error"
+
+// Version is the version of Elastic Common Schema which log entries conform to.
+const Version = "8.11.0"
+
+// New creates a new Handler with the given Option(s).
+// The handler formats records to match [Elastic Common Schema].
+//
+// [Elastic Common Schema]: https://www.elastic.co/guide/en/ecs/current/ecs-field-reference.html
+func New(opts ...Option) slog.Handler {
+	option := &options{}
+	for _, opt := range opts {
+		opt(option)
+	}
+	if option.writer == nil {
+		option.writer = os.Stderr
+	}
+
+	var handler slog.Handler
+	handler = slog.NewJSONHandler(
+		option.writer,
+		&slog.HandlerOptions{
+			AddSource:   true,
+			Level:       option.level,
+			ReplaceAttr: jsonlog.ReplaceAttr(fields()),
+		},
+	)
+	handler = handler.WithAttrs([]slog.Attr{slog.String("ecs.version", Version)})
+	if option.contextProvider != nil || option.errorStack {
+		if option.callers == nil {
+			option.callers = jsonlog.ErrorCallers
+		}
+
+		// Correlate logs with the trace and span.
+		//
+		// See: https://www.elastic.co/guide/en/ecs/current/ecs-tracing.html
+		//
+		// Add error details with the stack trace.
+		//
+		// See: https://www.elastic.co/guide/en/ecs/current/ecs-error.html
+		handler = jsonlog.Handler{
+			Handler:         handler,
+			ContextProvider: option.contextProvider,
+			TraceKey:        TraceKey,
+			SpanKey:         SpanKey,
+			ErrorStack:      option.errorStack,
+			Callers:         option.callers,
+			ErrorTypeKey:    "error.type",
+			StackKey:        "error.stack_trace",
+		}
+	}
+
+	return handler
+}
+
+// fields maps attributes to ECS fields.
+//
+// See: https://www.elastic.co/guide/en/ecs/current/ecs-base.html
+func fields() jsonlog.Fields {
+	return jsonlog.Fields{
+		Time:    "@timestamp",
+		Level:   "log.level",
+		Message: "message",
+		// See: https://www.elastic.co/guide/en/ecs/current/ecs-log.html#field-log-origin-file-name
+		Source: func(source *slog.Source) slog.Attr {
+			return slog.Group("log.origin",
+				slog.Group("file",
+					slog.String("name", source.File),
+					slog.Int("line", source.Line),
+				),
+				slog.String("function", source.Function),
+			)
+		},
+		// See: https://www.elastic.co/guide/en/ecs/current/ecs-tracing.html
+		TraceKey: TraceKey,
+		Trace:    "trace.id",
+		SpanKey:  SpanKey,
+		Span:     "span.id",
+		// See: https://www.elastic.co/guide/en/ecs/current/ecs-error.html
+		ErrorKey: ErrorKey,
+		Error:    "error.message",
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+package ecs_test
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/nil-go/sloth/ecs"
+	"github.com/nil-go/sloth/internal/jsonlog/jsonlogtest"
+)
+
+//nolint:lll
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	jsonlogtest.Run(t,
+		func(writer io.Writer) slog.Handler {
+			return ecs.New(
+				ecs.WithWriter(writer),
+				ecs.WithTrace(jsonlogtest.TraceContext),
+				ecs.WithErrorStack(true),
+			)
+		},
+		map[string]string{
+			"info":         `{"@timestamp":"1970-01-01T00:01:40.000001Z","log.level":"info","log.origin":{"file":{"name":"/jsonlogtest.go","line":76},"function":"github.com/nil-go/sloth/internal/jsonlog/jsonlogtest.Run.func1"},"message":"info","ecs.version":"8.11.0","trace.id":"4bf92f3577b34da6a3ce929d0e0e4736","span.id":"00f067aa0ba902b7","g":{"a":"A"}}`,
+			"warn":         `{"@timestamp":"1970-01-01T00:01:40.000001Z","log.level":"warn","log.origin":{"file":{"name":"/jsonlogtest.go","line":76},"function":"github.com/nil-go/sloth/internal/jsonlog/jsonlogtest.Run.func1"},"message":"warn","ecs.version":"8.11.0","trace.id":"4bf92f3577b34da6a3ce929d0e0e4736","span.id":"00f067aa0ba902b7"}`,
+			"error":        `{"@timestamp":"1970-01-01T00:01:40.000001Z","log.level":"error","log.origin":{"file":{"name":"/jsonlogtest.go","line":76},"function":"github.com/nil-go/sloth/internal/jsonlog/jsonlogtest.Run.func1"},"message":"error","ecs.version":"8.11.0","trace.id":"4bf92f3577b34da6a3ce929d0e0e4736","span.id":"00f067aa0ba902b7","error.type":"jsonlogtest.stackError","error.stack_trace":"goroutine 1 [running]:\ngithub.com/nil-go/sloth/internal/jsonlog/jsonlogtest.stackError.Callers()\n\t/jsonlogtest.go:104","error.message":"an error"}`,
+			"custom level": `{"@timestamp":"1970-01-01T00:01:40.000001Z","log.level":"info","log.origin":{"file":{"name":"/jsonlogtest.go","line":76},"function":"github.com/nil-go/sloth/internal/jsonlog/jsonlogtest.Run.func1"},"message":"custom level","ecs.version":"8.11.0","trace.id":"4bf92f3577b34da6a3ce929d0e0e4736","span.id":"00f067aa0ba902b7"}`,
+		},
+	)
+}
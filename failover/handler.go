@@ -0,0 +1,88 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+/*
+Package failover provides a handler for falling back to other handlers if the primary handler fails.
+
+It tries the primary handler first and, if it returns an error, tries each fallback handler in order
+until one succeeds, so that records are not lost if the primary destination is unavailable.
+
+	handler := failover.New(primary, slog.NewJSONHandler(os.Stderr, nil))
+*/
+package failover
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// Handler forwards records to the primary handler and falls back to other handlers on error.
+//
+// To create a new Handler, call [New].
+type Handler struct {
+	handlers []slog.Handler
+}
+
+// New creates a new Handler that forwards records to the primary handler,
+// and falls back to the given fallback handlers in order if the primary handler returns an error.
+func New(primary slog.Handler, fallbacks ...slog.Handler) Handler {
+	handlers := append([]slog.Handler{primary}, fallbacks...)
+	for _, handler := range handlers {
+		if handler == nil {
+			panic("cannot create Handler with nil handler")
+		}
+	}
+
+	return Handler{handlers: handlers}
+}
+
+// Enabled reports whether the primary handler is enabled for the level,
+// since fallback handlers only handle records which the primary handler fails to handle.
+func (h Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handlers[0].Enabled(ctx, level)
+}
+
+func (h Handler) Handle(ctx context.Context, record slog.Record) error {
+	// The record is dropped if the primary handler is not enabled for it.
+	if !h.handlers[0].Enabled(ctx, record.Level) {
+		return nil
+	}
+
+	var errs []error
+	for i, handler := range h.handlers {
+		// The fallback handler is skipped if it's not enabled for the record.
+		if i > 0 && !handler.Enabled(ctx, record.Level) {
+			continue
+		}
+
+		// Clone the record so that the failed handler could not interfere the fallback handler.
+		err := handler.Handle(ctx, record.Clone())
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+func (h Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		handlers[i] = handler.WithAttrs(attrs)
+	}
+	h.handlers = handlers
+
+	return h
+}
+
+func (h Handler) WithGroup(name string) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		handlers[i] = handler.WithGroup(name)
+	}
+	h.handlers = handlers
+
+	return h
+}
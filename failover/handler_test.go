@@ -0,0 +1,137 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+package failover_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/nil-go/sloth/failover"
+	"github.com/nil-go/sloth/internal/assert"
+)
+
+func TestNew_panic(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		assert.Equal(t, "cannot create Handler with nil handler", recover().(string))
+	}()
+
+	failover.New(nil)
+	t.Fail()
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		primary     slog.Handler
+		fallbacks   func(*bytes.Buffer) []slog.Handler
+		expected    string
+		err         string
+	}{
+		{
+			description: "primary succeeds",
+			primary:     errorHandler{},
+			fallbacks: func(buf *bytes.Buffer) []slog.Handler {
+				return []slog.Handler{textHandler(buf)}
+			},
+		},
+		{
+			description: "primary fails",
+			primary:     errorHandler{err: errors.New("primary error")},
+			fallbacks: func(buf *bytes.Buffer) []slog.Handler {
+				return []slog.Handler{textHandler(buf)}
+			},
+			expected: `level=INFO msg=info g.a=A
+`,
+		},
+		{
+			description: "fallback in order",
+			primary:     errorHandler{err: errors.New("primary error")},
+			fallbacks: func(buf *bytes.Buffer) []slog.Handler {
+				return []slog.Handler{errorHandler{err: errors.New("fallback error")}, textHandler(buf), errorHandler{}}
+			},
+			expected: `level=INFO msg=info g.a=A
+`,
+		},
+		{
+			description: "all fail",
+			primary:     errorHandler{err: errors.New("primary error")},
+			fallbacks: func(*bytes.Buffer) []slog.Handler {
+				return []slog.Handler{errorHandler{err: errors.New("fallback error")}}
+			},
+			err: "primary error\nfallback error",
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.description, func(t *testing.T) {
+			t.Parallel()
+
+			buf := &bytes.Buffer{}
+			handler := failover.New(testcase.primary, testcase.fallbacks(buf)...).
+				WithGroup("g").WithAttrs([]slog.Attr{slog.String("a", "A")})
+
+			err := handler.Handle(context.Background(), slog.Record{Level: slog.LevelInfo, Message: "info"})
+			if testcase.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.Equal(t, testcase.err, err.Error())
+			}
+			assert.Equal(t, testcase.expected, buf.String())
+		})
+	}
+}
+
+func TestHandler_enabled(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	handler := failover.New(
+		errorHandler{err: errors.New("primary error"), level: slog.LevelWarn},
+		textHandler(buf),
+	)
+	assert.Equal(t, false, handler.Enabled(context.Background(), slog.LevelInfo))
+	assert.Equal(t, true, handler.Enabled(context.Background(), slog.LevelWarn))
+
+	// The record is dropped since the primary handler is not enabled for the level.
+	assert.NoError(t, handler.Handle(context.Background(), slog.Record{Level: slog.LevelInfo, Message: "info"}))
+	assert.Equal(t, "", buf.String())
+
+	// The fallback handler only handles the record after the primary handler fails.
+	err := handler.Handle(context.Background(), slog.Record{Level: slog.LevelWarn, Message: "warn"})
+	assert.NoError(t, err)
+	assert.Equal(t, `level=WARN msg=warn
+`, buf.String())
+}
+
+func textHandler(buf *bytes.Buffer) slog.Handler {
+	return slog.NewTextHandler(buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if len(groups) == 0 && attr.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+
+			return attr
+		},
+	})
+}
+
+type errorHandler struct {
+	err   error
+	level slog.Level
+}
+
+func (h errorHandler) Enabled(_ context.Context, level slog.Level) bool { return level >= h.level }
+
+func (h errorHandler) Handle(context.Context, slog.Record) error { return h.err }
+
+func (h errorHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h errorHandler) WithGroup(string) slog.Handler { return h }
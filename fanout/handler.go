@@ -0,0 +1,82 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+/*
+Package fanout provides a handler for teeing records to multiple handlers.
+
+It forwards each record to all handlers enabled for the level of the record,
+e.g. writing records to both the gcp handler and a local text handler for development.
+
+	handler := fanout.New(gcp.New(), slog.NewTextHandler(os.Stderr, nil))
+*/
+package fanout
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// Handler forwards records to all the given handlers.
+//
+// To create a new Handler, call [New].
+type Handler struct {
+	handlers []slog.Handler
+}
+
+// New creates a new Handler that forwards records to the given handlers.
+func New(handlers ...slog.Handler) Handler {
+	for _, handler := range handlers {
+		if handler == nil {
+			panic("cannot create Handler with nil handler")
+		}
+	}
+
+	return Handler{handlers: handlers}
+}
+
+func (h Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h.handlers {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (h Handler) Handle(ctx context.Context, record slog.Record) error {
+	var errs []error
+	for _, handler := range h.handlers {
+		if !handler.Enabled(ctx, record.Level) {
+			continue
+		}
+
+		// Clone the record so that handlers could not interfere each other by adding attributes.
+		if err := handler.Handle(ctx, record.Clone()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (h Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		handlers[i] = handler.WithAttrs(attrs)
+	}
+	h.handlers = handlers
+
+	return h
+}
+
+func (h Handler) WithGroup(name string) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		handlers[i] = handler.WithGroup(name)
+	}
+	h.handlers = handlers
+
+	return h
+}
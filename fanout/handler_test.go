@@ -0,0 +1,125 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+package fanout_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/nil-go/sloth/fanout"
+	"github.com/nil-go/sloth/internal/assert"
+)
+
+func TestNew_panic(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		assert.Equal(t, "cannot create Handler with nil handler", recover().(string))
+	}()
+
+	fanout.New(nil)
+	t.Fail()
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		handler     func(slog.Handler) slog.Handler
+		expected    string
+	}{
+		{
+			description: "with attrs",
+			handler: func(handler slog.Handler) slog.Handler {
+				return handler.WithAttrs([]slog.Attr{slog.String("a", "A")})
+			},
+			expected: `level=INFO msg=info a=A
+`,
+		},
+		{
+			description: "with group",
+			handler: func(handler slog.Handler) slog.Handler {
+				return handler.WithGroup("g").WithAttrs([]slog.Attr{slog.String("a", "A")})
+			},
+			expected: `level=INFO msg=info g.a=A
+`,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.description, func(t *testing.T) {
+			t.Parallel()
+
+			buf1, buf2 := &bytes.Buffer{}, &bytes.Buffer{}
+			handler := fanout.New(textHandler(buf1, slog.LevelInfo), textHandler(buf2, slog.LevelInfo))
+			logger := slog.New(testcase.handler(handler))
+			logger.Info("info")
+
+			assert.Equal(t, testcase.expected, buf1.String())
+			assert.Equal(t, testcase.expected, buf2.String())
+		})
+	}
+}
+
+func TestHandler_enabled(t *testing.T) {
+	t.Parallel()
+
+	debugBuf, warnBuf := &bytes.Buffer{}, &bytes.Buffer{}
+	handler := fanout.New(textHandler(debugBuf, slog.LevelDebug), textHandler(warnBuf, slog.LevelWarn))
+	assert.Equal(t, true, handler.Enabled(context.Background(), slog.LevelDebug))
+	assert.Equal(t, false, fanout.New().Enabled(context.Background(), slog.LevelError))
+
+	logger := slog.New(handler)
+	logger.Debug("debug")
+	logger.Warn("warn")
+
+	assert.Equal(t, `level=DEBUG msg=debug
+level=WARN msg=warn
+`, debugBuf.String())
+	assert.Equal(t, `level=WARN msg=warn
+`, warnBuf.String())
+}
+
+func TestHandler_error(t *testing.T) {
+	t.Parallel()
+
+	err1, err2 := errors.New("error 1"), errors.New("error 2")
+	buf := &bytes.Buffer{}
+	handler := fanout.New(errorHandler{err1}, textHandler(buf, slog.LevelInfo), errorHandler{err2})
+
+	err := handler.Handle(context.Background(), slog.Record{Level: slog.LevelInfo, Message: "info"})
+	assert.Equal(t, "error 1\nerror 2", err.Error())
+	assert.Equal(t, true, errors.Is(err, err1) && errors.Is(err, err2))
+	assert.Equal(t, `level=INFO msg=info
+`, buf.String())
+}
+
+func textHandler(buf *bytes.Buffer, level slog.Level) slog.Handler {
+	return slog.NewTextHandler(buf, &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if len(groups) == 0 && attr.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+
+			return attr
+		},
+	})
+}
+
+type errorHandler struct {
+	err error
+}
+
+func (errorHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h errorHandler) Handle(context.Context, slog.Record) error { return h.err }
+
+func (h errorHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h errorHandler) WithGroup(string) slog.Handler { return h }
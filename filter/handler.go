@@ -0,0 +1,73 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+/*
+Package filter provides a handler for filtering records by the given predicate.
+
+It forwards records to the wrapped handler only if the predicate returns true,
+e.g. routing only audit records to a particular destination.
+
+	handler := filter.New(auditHandler, func(_ context.Context, record slog.Record) bool {
+		audit := false
+		record.Attrs(func(attr slog.Attr) bool {
+			audit = attr.Key == "audit" && attr.Value.Equal(slog.BoolValue(true))
+
+			return !audit
+		})
+
+		return audit
+	})
+*/
+package filter
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Handler filters records with the given predicate.
+//
+// To create a new Handler, call [New].
+type Handler struct {
+	handler slog.Handler
+	keep    func(ctx context.Context, record slog.Record) bool
+}
+
+// New creates a new Handler that forwards records to the handler only if keep returns true.
+//
+// The record passed to keep only has the attributes added to the record itself,
+// not the attributes added by Handler.WithAttrs.
+func New(handler slog.Handler, keep func(ctx context.Context, record slog.Record) bool) Handler {
+	if handler == nil {
+		panic("cannot create Handler with nil handler")
+	}
+	if keep == nil {
+		panic("cannot create Handler with nil keep function")
+	}
+
+	return Handler{handler: handler, keep: keep}
+}
+
+func (h Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h Handler) Handle(ctx context.Context, record slog.Record) error {
+	if !h.keep(ctx, record) {
+		return nil
+	}
+
+	return h.handler.Handle(ctx, record)
+}
+
+func (h Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h.handler = h.handler.WithAttrs(attrs)
+
+	return h
+}
+
+func (h Handler) WithGroup(name string) slog.Handler {
+	h.handler = h.handler.WithGroup(name)
+
+	return h
+}
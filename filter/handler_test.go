@@ -0,0 +1,87 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+package filter_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/nil-go/sloth/filter"
+	"github.com/nil-go/sloth/internal/assert"
+)
+
+func TestNew_panic(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		handler     slog.Handler
+		keep        func(context.Context, slog.Record) bool
+		err         string
+	}{
+		{
+			description: "handler is nil",
+			keep:        func(context.Context, slog.Record) bool { return true },
+			err:         "cannot create Handler with nil handler",
+		},
+		{
+			description: "keep is nil",
+			handler:     slog.Default().Handler(),
+			err:         "cannot create Handler with nil keep function",
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.description, func(t *testing.T) {
+			t.Parallel()
+
+			defer func() {
+				assert.Equal(t, testcase.err, recover().(string))
+			}()
+
+			filter.New(testcase.handler, testcase.keep)
+			t.Fail()
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	handler := filter.New(textHandler(buf), func(_ context.Context, record slog.Record) bool {
+		audit := false
+		record.Attrs(func(attr slog.Attr) bool {
+			audit = attr.Key == "audit" && attr.Value.Equal(slog.BoolValue(true))
+
+			return !audit
+		})
+
+		return audit
+	})
+	assert.Equal(t, false, handler.Enabled(context.Background(), slog.LevelDebug))
+
+	logger := slog.New(handler).WithGroup("g").With("a", "A")
+	logger.Info("kept", "audit", true)
+	logger.Info("dropped", "audit", false)
+	logger.Info("dropped")
+	logger.Debug("disabled", "audit", true)
+
+	assert.Equal(t, `level=INFO msg=kept g.a=A g.audit=true
+`, buf.String())
+}
+
+func textHandler(buf *bytes.Buffer) slog.Handler {
+	return slog.NewTextHandler(buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if len(groups) == 0 && attr.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+
+			return attr
+		},
+	})
+}
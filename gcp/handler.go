@@ -16,14 +16,19 @@ package gcp
 
 import (
 	"context"
+	"crypto/rand"
 	"encoding/hex"
 	"errors"
+	"io"
 	"log/slog"
 	"os"
+	"reflect"
 	"runtime"
 	"slices"
 	"strconv"
 	"strings"
+
+	"github.com/nil-go/sloth/internal/jsonlog"
 )
 
 // Keys for [W3C Trace Context] attributes by following [Trace Context in non-OTLP Log Formats].
@@ -51,51 +56,192 @@ const (
 	TraceFlagsKey = "trace_flags"
 )
 
+// TraceContext is the [W3C Trace Context] of the span associated with the context,
+// which is provided by the function set in WithSpanContext.
+//
+// [W3C Trace Context]: https://www.w3.org/TR/trace-context/#traceparent-header-field-values
+type TraceContext struct {
+	// TraceID is the ID of the whole trace. All bytes as zero is considered an invalid value.
+	TraceID [16]byte
+	// SpanID is the ID of the span as known by the caller.
+	SpanID [8]byte
+	// TraceFlags is the 8-bit field that controls tracing flags such as sampling.
+	TraceFlags byte
+	// TraceState carries vendor-specific trace identification data, which is not logged by the handler.
+	TraceState string
+	// Remote reports whether the span context is propagated from a remote parent.
+	Remote bool
+}
+
+// SpanContextKey is the key of the group attribute which carries TraceKey, SpanKey and TraceFlagsKey
+// if WithTraceFormat is TraceFormatOTel.
+const SpanContextKey = "span_context"
+
+// TraceFormat is the format of trace information in the log.
+type TraceFormat int
+
+const (
+	// TraceFormatGCP emits trace information as [special fields] of GCP Cloud Logging,
+	// e.g. `logging.googleapis.com/trace`.
+	//
+	// [special fields]: https://cloud.google.com/logging/docs/agent/logging/configuration#special-fields
+	TraceFormatGCP TraceFormat = iota
+	// TraceFormatOTel emits trace information as a nested object like the SpanContext of Open Telemetry,
+	// e.g. `"span_context":{"trace_id":"...","span_id":"...","trace_flags":"01"}`.
+	TraceFormatOTel
+)
+
+// SourceField is the bit flags of fields in the source location of the log entry.
+type SourceField int
+
+const (
+	// SourceFile is the absolute path of the source file.
+	SourceFile SourceField = 1 << iota
+	// SourceLine is the line number in the source file.
+	SourceLine
+	// SourceFunction is the function name of the source.
+	SourceFunction
+)
+
+// ErrorChainKey is the key of the attribute which carries the chain of the error in the record
+// with type names and messages if WithStructuredError is enabled.
+const ErrorChainKey = "error_chain"
+
+// Flusher is implemented by writers which buffer written log entries, e.g. an async writer,
+// so the buffered log entries could be flushed on shutdown.
+type Flusher interface {
+	Flush() error
+}
+
 // New creates a new Handler with the given Option(s).
 // The handler formats records to match [GCP Cloud Logging JSON schema].
 //
+// If the writer provided by WithWriter implements Flusher, or implements io.Closer while WithCloseWriter is enabled,
+// the returned handler and handlers derived from it implement io.Closer,
+// which flushes the writer and closes it if WithCloseWriter is enabled.
+// Callers should type-assert and Close it on shutdown:
+//
+//	if closer, ok := handler.(io.Closer); ok {
+//		defer closer.Close()
+//	}
+//
 // [GCP Cloud Logging JSON schema]: https://cloud.google.com/logging/docs/agent/logging/configuration#special-fields
 func New(opts ...Option) slog.Handler {
 	option := &options{}
 	for _, opt := range opts {
 		opt(option)
 	}
+	writer := option.writer
 	if option.writer == nil {
 		option.writer = os.Stderr
 	}
 
-	var handler slog.Handler
-	handler = slog.NewJSONHandler(
+	if len(option.promotedKeys) > 0 {
+		option.writer = &promoteWriter{writer: option.writer, keys: option.promotedKeys}
+	}
+
+	if option.innerHandler == nil {
+		option.innerHandler = func(writer io.Writer, opts *slog.HandlerOptions) slog.Handler {
+			return slog.NewJSONHandler(writer, opts)
+		}
+	}
+	handler := option.innerHandler(
 		option.writer,
 		&slog.HandlerOptions{
 			AddSource:   true,
 			Level:       option.level,
-			ReplaceAttr: replaceAttr(option.project),
+			ReplaceAttr: replaceAttr(option.project, option.traceFormat, option.fieldNames, option.sourceFields),
 		},
 	)
-	if option.project != "" || option.service != "" {
+	if handler == nil {
+		panic("cannot create Handler with nil inner handler")
+	}
+	if option.project != "" || option.service != "" || option.structuredError || option.logName != nil {
 		if option.callers == nil {
-			option.callers = func(err error) []uintptr {
-				var callers interface{ Callers() []uintptr }
-				if errors.As(err, &callers) {
-					return callers.Callers()
-				}
-
-				return nil
-			}
+			option.callers = jsonlog.ErrorCallers
 		}
 
 		handler = logHandler{
 			handler:         handler,
 			contextProvider: option.contextProvider,
-			service:         option.service, version: option.version, callers: option.callers,
+			service:         option.service,
+			version:         option.version,
+			callers:         option.callers,
+			stackFilter:     option.stackFilter,
+			goroutineID:     option.goroutineID,
+			errorKey:        option.errorKey,
+			structuredError: option.structuredError,
+			traceFormat:     option.traceFormat,
+			logName:         option.logName,
+			syntheticTrace:  option.syntheticTrace,
+			traceGroup:      option.traceGroup,
 		}
 	}
 
+	// The writer is only closed if the caller opts in, since the handler does not own it, e.g. os.Stderr.
+	_, flushable := writer.(Flusher)
+	_, closable := writer.(io.Closer)
+	if flushable || closable && option.closeWriter {
+		handler = closeHandler{handler: handler, writer: writer, close: option.closeWriter}
+	}
+
 	return handler
 }
 
-func replaceAttr(project string) func(groups []string, attr slog.Attr) slog.Attr { //nolint:cyclop,funlen
+type closeHandler struct {
+	handler slog.Handler
+	writer  io.Writer
+	close   bool
+}
+
+func (h closeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h closeHandler) Handle(ctx context.Context, record slog.Record) error {
+	return h.handler.Handle(ctx, record)
+}
+
+func (h closeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h.handler = h.handler.WithAttrs(attrs)
+
+	return h
+}
+
+func (h closeHandler) WithGroup(name string) slog.Handler {
+	h.handler = h.handler.WithGroup(name)
+
+	return h
+}
+
+// Close flushes the writer if it implements Flusher, and then closes it
+// if it implements io.Closer and WithCloseWriter is enabled.
+func (h closeHandler) Close() error {
+	var errs []error
+	if flusher, ok := h.writer.(Flusher); ok {
+		errs = append(errs, flusher.Flush())
+	}
+	if closer, ok := h.writer.(io.Closer); ok && h.close {
+		errs = append(errs, closer.Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+func replaceAttr( //nolint:cyclop,funlen
+	project string,
+	traceFormat TraceFormat,
+	fieldNames map[string]string,
+	sourceFields SourceField,
+) func(groups []string, attr slog.Attr) slog.Attr {
+	name := func(key string) string {
+		if name := fieldNames[key]; name != "" {
+			return name
+		}
+
+		return key
+	}
+
 	return func(groups []string, attr slog.Attr) slog.Attr {
 		if len(groups) > 0 {
 			return attr
@@ -110,8 +256,9 @@ func replaceAttr(project string) func(groups []string, attr slog.Attr) slog.Attr
 		// See: https://cloud.google.com/logging/docs/reference/v2/rest/v2/LogEntry#LogSeverity
 		case slog.LevelKey:
 			var severity string
-			if level, ok := attr.Value.Resolve().Any().(slog.Level); ok {
-				switch {
+			// Resolve any slog.Leveler, including slog.Level, to the concrete level.
+			if leveler, ok := attr.Value.Resolve().Any().(slog.Leveler); ok {
+				switch level := leveler.Level(); {
 				case level >= slog.LevelError:
 					severity = "ERROR"
 				case level >= slog.LevelWarn:
@@ -123,7 +270,7 @@ func replaceAttr(project string) func(groups []string, attr slog.Attr) slog.Attr
 				}
 			}
 
-			return slog.String("severity", severity)
+			return slog.String(name("severity"), severity)
 
 		// Format event timestamp according to GCP JSON formats.
 		//
@@ -132,7 +279,7 @@ func replaceAttr(project string) func(groups []string, attr slog.Attr) slog.Attr
 			time := attr.Value.Resolve().Time()
 
 			return slog.Attr{
-				Key: "timestamp",
+				Key: name("timestamp"),
 				Value: slog.GroupValue(
 					slog.Int64("seconds", time.Unix()),
 					slog.Int64("nanos", int64(time.Nanosecond())),
@@ -140,12 +287,28 @@ func replaceAttr(project string) func(groups []string, attr slog.Attr) slog.Attr
 			}
 
 		case slog.SourceKey:
-			attr.Key = "logging.googleapis.com/sourceLocation"
+			attr.Key = name("logging.googleapis.com/sourceLocation")
+			source, ok := attr.Value.Resolve().Any().(*slog.Source)
+			if !ok || sourceFields == 0 || sourceFields == SourceFile|SourceLine|SourceFunction {
+				return attr
+			}
 
-			return attr
+			// Rebuild the source location with selected fields only.
+			var fields []slog.Attr
+			if sourceFields&SourceFunction != 0 {
+				fields = append(fields, slog.String("function", source.Function))
+			}
+			if sourceFields&SourceFile != 0 {
+				fields = append(fields, slog.String("file", source.File))
+			}
+			if sourceFields&SourceLine != 0 {
+				fields = append(fields, slog.Int("line", source.Line))
+			}
+
+			return slog.Attr{Key: attr.Key, Value: slog.GroupValue(fields...)}
 
 		case slog.MessageKey:
-			attr.Key = "message"
+			attr.Key = name("message")
 
 			return attr
 		}
@@ -153,20 +316,22 @@ func replaceAttr(project string) func(groups []string, attr slog.Attr) slog.Attr
 		// Associate logs with a trace and span.
 		//
 		// See: https://cloud.google.com/trace/docs/trace-log-integration
-		if project != "" {
+		if project != "" && traceFormat == TraceFormatGCP {
 			switch attr.Key {
 			case TraceKey:
-				return slog.String("logging.googleapis.com/trace", "projects/"+project+"/traces/"+attr.Value.Resolve().String())
+				traceID, ok := normalizeTraceID(attr.Value.Resolve().String())
+				if !ok {
+					// Pass through the invalid trace id as is since it could not link to the trace.
+					return attr
+				}
+
+				return slog.String("logging.googleapis.com/trace", "projects/"+project+"/traces/"+traceID)
 			case SpanKey:
 				attr.Key = "logging.googleapis.com/spanId"
 
 				return attr
 			case TraceFlagsKey:
-				var sampled bool
-				flags, _ := hex.DecodeString(attr.Value.Resolve().String())
-				if len(flags) > 0 {
-					sampled = flags[0]&0x1 == 0x1 //nolint:mnd
-				}
+				sampled := parseTraceFlags(attr.Value.Resolve().String())&0x1 == 0x1 //nolint:mnd
 
 				return slog.Bool("logging.googleapis.com/trace_sampled", sampled)
 			}
@@ -176,22 +341,62 @@ func replaceAttr(project string) func(groups []string, attr slog.Attr) slog.Attr
 	}
 }
 
+// normalizeTraceID normalizes the trace id to 32 lowercase hex characters, left-padding short ids with zeros
+// and keeping the last 32 characters of long ids. It reports false if the trace id is not hex.
+func normalizeTraceID(traceID string) (string, bool) {
+	const length = 32
+
+	if traceID == "" {
+		return "", false
+	}
+	for _, char := range traceID {
+		if !('0' <= char && char <= '9' || 'a' <= char && char <= 'f' || 'A' <= char && char <= 'F') {
+			return "", false
+		}
+	}
+
+	traceID = strings.ToLower(traceID)
+	if len(traceID) < length {
+		return strings.Repeat("0", length-len(traceID)) + traceID, true
+	}
+
+	return traceID[len(traceID)-length:], true
+}
+
+// parseTraceFlags parses the trace flags in hex with one or two digits, e.g. "1" and "01",
+// or as the raw byte if it's a single non-hex character. It returns 0 (unsampled) if it's unparseable.
+func parseTraceFlags(value string) byte {
+	if flags, err := strconv.ParseUint(value, 16, 8); err == nil {
+		return byte(flags)
+	}
+	if len(value) == 1 {
+		return value[0]
+	}
+
+	return 0
+}
+
 type (
 	logHandler struct {
 		handler slog.Handler
 
-		contextProvider func(context.Context) (traceID [16]byte, spanID [8]byte, traceFlags byte)
+		contextProvider func(context.Context) TraceContext
+		syntheticTrace  bool
+		traceGroup      *traceGroup
 		hasTrace        bool
+		traceFormat     TraceFormat
 
-		service string
-		version string
-		callers func(error) []uintptr
+		service     string
+		version     string
+		callers     func(error) []uintptr
+		stackFilter func(runtime.Frame) bool
+		goroutineID func(context.Context) uint64
+		errorKey    string
 
-		groups []group
-	}
-	group struct {
-		name  string
-		attrs []slog.Attr
+		structuredError bool
+		logName         func(context.Context) string
+
+		groups jsonlog.Groups
 	}
 )
 
@@ -202,32 +407,52 @@ func (h logHandler) Enabled(ctx context.Context, level slog.Level) bool {
 func (h logHandler) Handle(ctx context.Context, record slog.Record) error { //nolint:cyclop,funlen
 	var attrs []slog.Attr
 
+	// Trace groups in the record are flattened only if there are no groups,
+	// otherwise they are not at the root of the log.
+	if h.traceGroup != nil && len(h.groups) == 0 {
+		var recordAttrs []slog.Attr
+		record.Attrs(func(attr slog.Attr) bool {
+			recordAttrs = append(recordAttrs, attr)
+
+			return true
+		})
+		if flattened, ok := h.traceGroup.flatten(recordAttrs); ok {
+			record = slog.NewRecord(record.Time, record.Level, record.Message, record.PC)
+			record.AddAttrs(flattened...)
+		}
+	}
+
 	// Associate logs with a trace and span.
 	//
 	// See: https://cloud.google.com/trace/docs/trace-log-integration
-	if !h.hasTrace && h.contextProvider != nil { //nolint:nestif
+	if !h.hasTrace && (h.contextProvider != nil || h.syntheticTrace) { //nolint:nestif
 		var found bool
 		// Only search for trace attributes if there are no groups.
 		if len(h.groups) == 0 {
 			record.Attrs(func(attr slog.Attr) bool {
-				if attr.Key == TraceKey {
-					found = true
+				found = isTrace(attr)
 
-					return false
-				}
-
-				return true
+				return !found
 			})
 		}
 
 		if !found {
-			if traceID, spanID, traceFlags := h.contextProvider(ctx); traceID != [16]byte{} {
-				attrs = append(attrs,
-					slog.String(TraceKey, hex.EncodeToString(traceID[:])),
-					slog.String(SpanKey, hex.EncodeToString(spanID[:])),
-					slog.String(TraceFlagsKey, hex.EncodeToString([]byte{traceFlags})),
-				)
-			}
+			attrs = append(attrs, h.nestTrace(h.traceAttrs(ctx))...)
+		}
+	}
+
+	// Trace attributes in the record are nested only if there are no groups,
+	// otherwise they are not at the root of the log.
+	if h.traceFormat == TraceFormatOTel && len(h.groups) == 0 {
+		var recordAttrs []slog.Attr
+		record.Attrs(func(attr slog.Attr) bool {
+			recordAttrs = append(recordAttrs, attr)
+
+			return true
+		})
+		if nested := h.nestTrace(recordAttrs); len(nested) != len(recordAttrs) {
+			record = slog.NewRecord(record.Time, record.Level, record.Message, record.PC)
+			record.AddAttrs(nested...)
 		}
 	}
 
@@ -237,18 +462,12 @@ func (h logHandler) Handle(ctx context.Context, record slog.Record) error { //no
 	if record.Level >= slog.LevelError && h.service != "" {
 		firstFrame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
 		var callers []uintptr
-		record.Attrs(func(attr slog.Attr) bool {
-			if err, ok := attr.Value.Resolve().Any().(error); ok {
-				callers = h.callers(err)
-
-				return false
-			}
-
-			return true
-		})
+		if err := jsonlog.RecordError(record, h.errorKey); err != nil {
+			callers = h.callers(err)
+		}
 
 		if len(callers) == 0 {
-			callers = loadCallers(firstFrame)
+			callers = jsonlog.Callers(firstFrame)
 		}
 
 		attrs = append(attrs,
@@ -265,110 +484,249 @@ func (h logHandler) Handle(ctx context.Context, record slog.Record) error { //no
 					},
 				),
 			},
-			slog.Attr{
-				Key: "serviceContext",
-				Value: slog.GroupValue(
-					slog.String("service", h.service),
-					slog.String("version", h.version),
-				),
-			},
-			slog.String("stack_trace", stack(record.Message, callers)),
+			slog.Attr{Key: "serviceContext", Value: h.serviceContext()},
+			slog.String("stack_trace", h.stack(ctx, record.Message, callers)),
 		)
 	}
 
-	// Have to add the attributes to the handler before adding the group.
-	// Otherwise, the attributes are added to the group.
-	handler := h.handler.WithAttrs(attrs)
-	for _, group := range h.groups {
-		handler = handler.WithGroup(group.name).WithAttrs(group.attrs)
+	if h.logName != nil {
+		if logName := h.logName(ctx); logName != "" {
+			attrs = append(attrs, slog.String("logName", logName))
+		}
+	}
+
+	if record.Level >= slog.LevelError && h.structuredError {
+		if err := jsonlog.RecordError(record, h.errorKey); err != nil {
+			attrs = append(attrs, slog.Any(ErrorChainKey, errorChain(err)))
+		}
 	}
 
-	return handler.Handle(ctx, record)
+	return h.groups.Replay(h.handler, attrs).Handle(ctx, record)
 }
 
-func loadCallers(firstFrame runtime.Frame) []uintptr {
-	var pcs [32]uintptr
-	count := runtime.Callers(2, pcs[:]) //nolint:mnd // skip [runtime.Callers, this function]
-
-	// Skip frames before the first frame of the record.
-	callers := pcs[:count]
-	frames := runtime.CallersFrames(callers)
-	for {
-		frame, more := frames.Next()
-		if frame.Function == firstFrame.Function &&
-			frame.File == firstFrame.File &&
-			frame.Line == firstFrame.Line {
-			break
+func (h logHandler) traceAttrs(ctx context.Context) []slog.Attr {
+	if h.contextProvider != nil {
+		if traceContext := h.contextProvider(ctx); traceContext.TraceID != [16]byte{} {
+			return []slog.Attr{
+				slog.String(TraceKey, hex.EncodeToString(traceContext.TraceID[:])),
+				slog.String(SpanKey, hex.EncodeToString(traceContext.SpanID[:])),
+				slog.String(TraceFlagsKey, hex.EncodeToString([]byte{traceContext.TraceFlags})),
+			}
 		}
-		callers = callers[1:]
-		if !more {
-			break
+	}
+
+	// The synthetic trace has no span, so only the trace id is added.
+	if h.syntheticTrace {
+		if traceID, ok := ctx.Value(syntheticTraceKey{}).([16]byte); ok {
+			return []slog.Attr{slog.String(TraceKey, hex.EncodeToString(traceID[:]))}
 		}
 	}
 
-	if len(callers) > 0 {
-		return callers
+	return nil
+}
+
+type syntheticTraceKey struct{}
+
+// WithSyntheticTraceContext returns a copy of the context with a random trace id for the request,
+// which is added to logs by the handler with WithSyntheticTrace if there is no valid trace in the context.
+// It usually should be called at the beginning interceptor of the gRPC/HTTP request.
+//
+// It returns the context as is if it already has a synthetic trace id.
+func WithSyntheticTraceContext(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(syntheticTraceKey{}).([16]byte); ok {
+		return ctx
+	}
+
+	var traceID [16]byte
+	if _, err := rand.Read(traceID[:]); err != nil {
+		return ctx
 	}
 
-	// If the first frame is not found, all frames prints as stack trace.
-	return pcs[:count]
+	return context.WithValue(ctx, syntheticTraceKey{}, traceID)
 }
 
-func stack(message string, callers []uintptr) string {
-	var stackTrace strings.Builder
-	stackTrace.Grow(128 * len(callers)) //nolint:mnd // It assumes 128 bytes per frame.
+func (h logHandler) serviceContext() slog.Value {
+	// Omit the version if it's empty since Error Reporting does not accept empty version.
+	if h.version == "" {
+		return slog.GroupValue(slog.String("service", h.service))
+	}
+
+	return slog.GroupValue(
+		slog.String("service", h.service),
+		slog.String("version", h.version),
+	)
+}
 
+// traceGroup is the layout of the group attribute which carries trace information, set by WithTraceGroup.
+type traceGroup struct {
+	name     string
+	idKey    string
+	spanKey  string
+	flagsKey string
+}
+
+// flatten replaces the trace group in attributes with trace attributes, e.g. TraceKey,
+// and keeps other attributes in the group. It reports whether the trace group is found.
+func (g *traceGroup) flatten(attrs []slog.Attr) ([]slog.Attr, bool) {
+	index := slices.IndexFunc(attrs, func(attr slog.Attr) bool {
+		return attr.Key == g.name && attr.Value.Resolve().Kind() == slog.KindGroup
+	})
+	if index < 0 {
+		return attrs, false
+	}
+
+	var traceAttrs, others []slog.Attr
+	for _, attr := range attrs[index].Value.Resolve().Group() {
+		switch attr.Key {
+		case g.idKey:
+			traceAttrs = append(traceAttrs, slog.Attr{Key: TraceKey, Value: attr.Value})
+		case g.spanKey:
+			traceAttrs = append(traceAttrs, slog.Attr{Key: SpanKey, Value: attr.Value})
+		case g.flagsKey:
+			traceAttrs = append(traceAttrs, slog.Attr{Key: TraceFlagsKey, Value: attr.Value})
+		default:
+			others = append(others, attr)
+		}
+	}
+	if len(others) > 0 {
+		traceAttrs = append(traceAttrs, slog.Attr{Key: g.name, Value: slog.GroupValue(others...)})
+	}
+
+	return slices.Concat(attrs[:index], traceAttrs, attrs[index+1:]), true
+}
+
+// isTrace checks if the attribute is the trace attribute,
+// including attributes in inlined groups, e.g. a slog.LogValuer with empty key resolving to a group.
+func isTrace(attr slog.Attr) bool {
+	if attr.Key == TraceKey {
+		return true
+	}
+
+	if attr.Key == "" {
+		if value := attr.Value.Resolve(); value.Kind() == slog.KindGroup {
+			return slices.ContainsFunc(value.Group(), isTrace)
+		}
+	}
+
+	return false
+}
+
+type chainedError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// errorChain unwraps the error depth-first into the chain of errors with their type names.
+func errorChain(err error) []chainedError {
+	var chain []chainedError
+	var walk func(err error)
+	walk = func(err error) {
+		if err == nil {
+			return
+		}
+		chain = append(chain, chainedError{Type: reflect.TypeOf(err).String(), Message: err.Error()})
+
+		switch wrapped := err.(type) { //nolint:errorlint // It unwraps the error manually.
+		case interface{ Unwrap() error }:
+			walk(wrapped.Unwrap())
+		case interface{ Unwrap() []error }:
+			for _, e := range wrapped.Unwrap() {
+				walk(e)
+			}
+		}
+	}
+	walk(err)
+
+	return chain
+}
+
+func (h logHandler) goroutine(ctx context.Context) uint64 {
+	if h.goroutineID != nil {
+		if id := h.goroutineID(ctx); id != 0 {
+			return id
+		}
+	}
+
+	// Use 1 as the goroutine number as golang does not provide a way to get the current goroutine number.
+	return 1
+}
+
+func (h logHandler) stack(ctx context.Context, message string, callers []uintptr) string {
+	var stackTrace strings.Builder
+	// It has to start with the message to match the stack trace format for Error Reporting.
 	stackTrace.WriteString(message)
 	stackTrace.WriteString("\n\n")
-	// Always use 1 as the goroutine number as golang does not prove a way to get the current goroutine number.
-	// It's meaningless in stace trace since every log may have different goroutine number.
-	// It has to be a goroutine line to match the stack trace format for Error Reporting.
-	stackTrace.WriteString("goroutine 1 [running]:\n")
-
-	frames := runtime.CallersFrames(callers)
-	for {
-		// Each frame has 2 lines in stack trace.
-		frame, more := frames.Next()
-		// The first line is the function.
-		stackTrace.WriteString(frame.Function)
-		stackTrace.WriteString("()\n")
-		// The second line is the file:line.
-		stackTrace.WriteString("\t")
-		stackTrace.WriteString(frame.File)
-		stackTrace.WriteString(":")
-		stackTrace.WriteString(strconv.Itoa(frame.Line))
-		stackTrace.WriteString(" +0x")
-		stackTrace.WriteString(strconv.FormatUint(uint64(frame.PC-frame.Entry), 16))
-		stackTrace.WriteString("\n")
-
-		if !more {
-			break
+	jsonlog.Stack(&stackTrace, h.goroutine(ctx), callers, h.stackFilter)
+
+	return stackTrace.String()
+}
+
+// DefaultStackFilter drops frames of log/slog and sloth packages from the stack trace,
+// which could be used with WithStackFilter.
+func DefaultStackFilter(frame runtime.Frame) bool {
+	pkg := frame.Function
+	slash := max(strings.LastIndex(pkg, "/"), 0)
+	if dot := strings.Index(pkg[slash:], "."); dot >= 0 {
+		pkg = pkg[:slash+dot]
+	}
+
+	switch {
+	case pkg == "log/slog":
+		return false
+	case strings.HasPrefix(pkg, "github.com/nil-go/sloth/"):
+		return false
+	default:
+		return true
+	}
+}
+
+// nestTrace nests trace attributes into the group SpanContextKey if the trace format is TraceFormatOTel.
+func (h logHandler) nestTrace(attrs []slog.Attr) []slog.Attr {
+	if h.traceFormat != TraceFormatOTel {
+		return attrs
+	}
+
+	var traceAttrs []any
+	nested := make([]slog.Attr, 0, len(attrs))
+	index := -1
+	for _, attr := range attrs {
+		switch attr.Key {
+		case TraceKey, SpanKey, TraceFlagsKey:
+			if index < 0 {
+				index = len(nested)
+			}
+			traceAttrs = append(traceAttrs, attr)
+		default:
+			nested = append(nested, attr)
 		}
 	}
+	if index < 0 {
+		return attrs
+	}
 
-	return stackTrace.String()
+	return slices.Insert(nested, index, slog.Group(SpanContextKey, traceAttrs...))
 }
 
 func (h logHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
 	if len(h.groups) == 0 {
-		h.handler = h.handler.WithAttrs(attrs)
-		if slices.ContainsFunc(attrs, func(attr slog.Attr) bool { return attr.Key == TraceKey }) {
+		if h.traceGroup != nil {
+			attrs, _ = h.traceGroup.flatten(attrs)
+		}
+		h.handler = h.handler.WithAttrs(h.nestTrace(attrs))
+		if slices.ContainsFunc(attrs, isTrace) {
 			h.hasTrace = true
 		}
 
 		return h
 	}
 
-	h.groups = slices.Clone(h.groups)
-	h.groups[len(h.groups)-1].attrs = slices.Clone(h.groups[len(h.groups)-1].attrs)
-	h.groups[len(h.groups)-1].attrs = append(h.groups[len(h.groups)-1].attrs, attrs...)
+	h.groups = h.groups.WithAttrs(attrs)
 
 	return h
 }
 
 func (h logHandler) WithGroup(name string) slog.Handler {
-	h.groups = slices.Clone(h.groups)
-	h.groups = append(h.groups, group{name: name})
+	h.groups = h.groups.WithGroup(name)
 
 	return h
 }
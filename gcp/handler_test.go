@@ -4,9 +4,13 @@
 package gcp_test
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"runtime"
@@ -100,9 +104,9 @@ func testcases() []struct {
 	}{
 		{
 			description: "default",
-			expected: `{"timestamp":{"seconds":100,"nanos":1000},"severity":"INFO","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":39},"message":"info","a":"A","trace_id":"4bf92f3577b34da6a3ce929d0e0e4736","span_id":"00f067aa0ba902b7","trace_flags":"01"}
-{"timestamp":{"seconds":100,"nanos":1000},"severity":"WARNING","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":44},"message":"warn","g":{"b":"B","a":"A"}}
-{"timestamp":{"seconds":100,"nanos":1000},"severity":"ERROR","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":52},"message":"error","g":{"h":{"b":"B"}}}
+			expected: `{"timestamp":{"seconds":100,"nanos":1000},"severity":"INFO","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":43},"message":"info","a":"A","trace_id":"4bf92f3577b34da6a3ce929d0e0e4736","span_id":"00f067aa0ba902b7","trace_flags":"01"}
+{"timestamp":{"seconds":100,"nanos":1000},"severity":"WARNING","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":48},"message":"warn","g":{"b":"B","a":"A"}}
+{"timestamp":{"seconds":100,"nanos":1000},"severity":"ERROR","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":56},"message":"error","g":{"h":{"b":"B"}}}
 `,
 		},
 		{
@@ -110,8 +114,8 @@ func testcases() []struct {
 			opts: []gcp.Option{
 				gcp.WithLevel(slog.LevelWarn),
 			},
-			expected: `{"timestamp":{"seconds":100,"nanos":1000},"severity":"WARNING","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":44},"message":"warn","g":{"b":"B","a":"A"}}
-{"timestamp":{"seconds":100,"nanos":1000},"severity":"ERROR","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":52},"message":"error","g":{"h":{"b":"B"}}}
+			expected: `{"timestamp":{"seconds":100,"nanos":1000},"severity":"WARNING","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":48},"message":"warn","g":{"b":"B","a":"A"}}
+{"timestamp":{"seconds":100,"nanos":1000},"severity":"ERROR","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":56},"message":"error","g":{"h":{"b":"B"}}}
 `,
 		},
 		{
@@ -120,9 +124,9 @@ func testcases() []struct {
 				gcp.WithErrorReporting("test", "dev"),
 			},
 			err: errors.New("an error"),
-			expected: `{"timestamp":{"seconds":100,"nanos":1000},"severity":"INFO","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":39},"message":"info","a":"A","trace_id":"4bf92f3577b34da6a3ce929d0e0e4736","span_id":"00f067aa0ba902b7","trace_flags":"01"}
-{"timestamp":{"seconds":100,"nanos":1000},"severity":"WARNING","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":44},"message":"warn","g":{"b":"B","a":"A"}}
-{"timestamp":{"seconds":100,"nanos":1000},"severity":"ERROR","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":52},"message":"error","context":{"reportLocation":{"filePath":"/handler_test.go","lineNumber":52,"functionName":"github.com/nil-go/sloth/gcp_test.TestHandler.func1"}},"serviceContext":{"service":"test","version":"dev"},"stack_trace":"error\n\n\ngithub.com/nil-go/sloth/gcp_test.TestHandler.func1()\n\t/handler_test.go:52"g":{"h":{"b":"B","error":"an error"}}}
+			expected: `{"timestamp":{"seconds":100,"nanos":1000},"severity":"INFO","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":43},"message":"info","a":"A","trace_id":"4bf92f3577b34da6a3ce929d0e0e4736","span_id":"00f067aa0ba902b7","trace_flags":"01"}
+{"timestamp":{"seconds":100,"nanos":1000},"severity":"WARNING","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":48},"message":"warn","g":{"b":"B","a":"A"}}
+{"timestamp":{"seconds":100,"nanos":1000},"severity":"ERROR","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":56},"message":"error","context":{"reportLocation":{"filePath":"/handler_test.go","lineNumber":56,"functionName":"github.com/nil-go/sloth/gcp_test.TestHandler.func1"}},"serviceContext":{"service":"test","version":"dev"},"stack_trace":"error\n\n\ngithub.com/nil-go/sloth/gcp_test.TestHandler.func1()\n\t/handler_test.go:56"g":{"h":{"b":"B","error":"an error"}}}
 `,
 		},
 		{
@@ -137,9 +141,9 @@ func testcases() []struct {
 				}),
 			},
 			err: errors.New("an error"),
-			expected: `{"timestamp":{"seconds":100,"nanos":1000},"severity":"INFO","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":39},"message":"info","a":"A","trace_id":"4bf92f3577b34da6a3ce929d0e0e4736","span_id":"00f067aa0ba902b7","trace_flags":"01"}
-{"timestamp":{"seconds":100,"nanos":1000},"severity":"WARNING","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":44},"message":"warn","g":{"b":"B","a":"A"}}
-{"timestamp":{"seconds":100,"nanos":1000},"severity":"ERROR","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":52},"message":"error","context":{"reportLocation":{"filePath":"/handler_test.go","lineNumber":52,"functionName":"github.com/nil-go/sloth/gcp_test.TestHandler.func1"}},"serviceContext":{"service":"test","version":"dev"},"stack_trace":"error\n\n\ngithub.com/nil-go/sloth/gcp_test.testcases.func1()\n\t/handler_test.go:134"g":{"h":{"b":"B","error":"an error"}}}
+			expected: `{"timestamp":{"seconds":100,"nanos":1000},"severity":"INFO","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":43},"message":"info","a":"A","trace_id":"4bf92f3577b34da6a3ce929d0e0e4736","span_id":"00f067aa0ba902b7","trace_flags":"01"}
+{"timestamp":{"seconds":100,"nanos":1000},"severity":"WARNING","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":48},"message":"warn","g":{"b":"B","a":"A"}}
+{"timestamp":{"seconds":100,"nanos":1000},"severity":"ERROR","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":56},"message":"error","context":{"reportLocation":{"filePath":"/handler_test.go","lineNumber":56,"functionName":"github.com/nil-go/sloth/gcp_test.TestHandler.func1"}},"serviceContext":{"service":"test","version":"dev"},"stack_trace":"error\n\n\ngithub.com/nil-go/sloth/gcp_test.testcases.func1()\n\t/handler_test.go:138"g":{"h":{"b":"B","error":"an error"}}}
 `,
 		},
 		{
@@ -148,9 +152,9 @@ func testcases() []struct {
 				gcp.WithErrorReporting("test", "dev"),
 			},
 			err: stackError{errors.New("an error")},
-			expected: `{"timestamp":{"seconds":100,"nanos":1000},"severity":"INFO","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":39},"message":"info","a":"A","trace_id":"4bf92f3577b34da6a3ce929d0e0e4736","span_id":"00f067aa0ba902b7","trace_flags":"01"}
-{"timestamp":{"seconds":100,"nanos":1000},"severity":"WARNING","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":44},"message":"warn","g":{"b":"B","a":"A"}}
-{"timestamp":{"seconds":100,"nanos":1000},"severity":"ERROR","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":52},"message":"error","context":{"reportLocation":{"filePath":"/handler_test.go","lineNumber":52,"functionName":"github.com/nil-go/sloth/gcp_test.TestHandler.func1"}},"serviceContext":{"service":"test","version":"dev"},"stack_trace":"error\n\n\ngithub.com/nil-go/sloth/gcp_test.stackError.Callers()\n\t/handler_test.go:73"g":{"h":{"b":"B","error":"an error"}}}
+			expected: `{"timestamp":{"seconds":100,"nanos":1000},"severity":"INFO","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":43},"message":"info","a":"A","trace_id":"4bf92f3577b34da6a3ce929d0e0e4736","span_id":"00f067aa0ba902b7","trace_flags":"01"}
+{"timestamp":{"seconds":100,"nanos":1000},"severity":"WARNING","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":48},"message":"warn","g":{"b":"B","a":"A"}}
+{"timestamp":{"seconds":100,"nanos":1000},"severity":"ERROR","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":56},"message":"error","context":{"reportLocation":{"filePath":"/handler_test.go","lineNumber":56,"functionName":"github.com/nil-go/sloth/gcp_test.TestHandler.func1"}},"serviceContext":{"service":"test","version":"dev"},"stack_trace":"error\n\n\ngithub.com/nil-go/sloth/gcp_test.stackError.Callers()\n\t/handler_test.go:77"g":{"h":{"b":"B","error":"an error"}}}
 `,
 		},
 		{
@@ -158,9 +162,9 @@ func testcases() []struct {
 			opts: []gcp.Option{
 				gcp.WithTrace("test"),
 			},
-			expected: `{"timestamp":{"seconds":100,"nanos":1000},"severity":"INFO","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":39},"message":"info","a":"A","logging.googleapis.com/trace":"projects/test/traces/4bf92f3577b34da6a3ce929d0e0e4736","logging.googleapis.com/spanId":"00f067aa0ba902b7","logging.googleapis.com/trace_sampled":true}
-{"timestamp":{"seconds":100,"nanos":1000},"severity":"WARNING","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":44},"message":"warn","g":{"b":"B","a":"A"}}
-{"timestamp":{"seconds":100,"nanos":1000},"severity":"ERROR","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":52},"message":"error","g":{"h":{"b":"B"}}}
+			expected: `{"timestamp":{"seconds":100,"nanos":1000},"severity":"INFO","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":43},"message":"info","a":"A","logging.googleapis.com/trace":"projects/test/traces/4bf92f3577b34da6a3ce929d0e0e4736","logging.googleapis.com/spanId":"00f067aa0ba902b7","logging.googleapis.com/trace_sampled":true}
+{"timestamp":{"seconds":100,"nanos":1000},"severity":"WARNING","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":48},"message":"warn","g":{"b":"B","a":"A"}}
+{"timestamp":{"seconds":100,"nanos":1000},"severity":"ERROR","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":56},"message":"error","g":{"h":{"b":"B"}}}
 `,
 		},
 		{
@@ -173,10 +177,795 @@ func testcases() []struct {
 						1
 				}),
 			},
-			expected: `{"timestamp":{"seconds":100,"nanos":1000},"severity":"INFO","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":39},"message":"info","a":"A","logging.googleapis.com/trace":"projects/test/traces/4bf92f3577b34da6a3ce929d0e0e4736","logging.googleapis.com/spanId":"00f067aa0ba902b7","logging.googleapis.com/trace_sampled":true}
-{"timestamp":{"seconds":100,"nanos":1000},"severity":"WARNING","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":44},"message":"warn","logging.googleapis.com/trace":"projects/test/traces/4bf92f3577b34da6a3ce929d0e0e4736","logging.googleapis.com/spanId":"00f067aa0ba902b7","logging.googleapis.com/trace_sampled":true,"g":{"b":"B","a":"A"}}
-{"timestamp":{"seconds":100,"nanos":1000},"severity":"ERROR","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":52},"message":"error","logging.googleapis.com/trace":"projects/test/traces/4bf92f3577b34da6a3ce929d0e0e4736","logging.googleapis.com/spanId":"00f067aa0ba902b7","logging.googleapis.com/trace_sampled":true,"g":{"h":{"b":"B"}}}
+			expected: `{"timestamp":{"seconds":100,"nanos":1000},"severity":"INFO","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":43},"message":"info","a":"A","logging.googleapis.com/trace":"projects/test/traces/4bf92f3577b34da6a3ce929d0e0e4736","logging.googleapis.com/spanId":"00f067aa0ba902b7","logging.googleapis.com/trace_sampled":true}
+{"timestamp":{"seconds":100,"nanos":1000},"severity":"WARNING","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":48},"message":"warn","logging.googleapis.com/trace":"projects/test/traces/4bf92f3577b34da6a3ce929d0e0e4736","logging.googleapis.com/spanId":"00f067aa0ba902b7","logging.googleapis.com/trace_sampled":true,"g":{"b":"B","a":"A"}}
+{"timestamp":{"seconds":100,"nanos":1000},"severity":"ERROR","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":56},"message":"error","logging.googleapis.com/trace":"projects/test/traces/4bf92f3577b34da6a3ce929d0e0e4736","logging.googleapis.com/spanId":"00f067aa0ba902b7","logging.googleapis.com/trace_sampled":true,"g":{"h":{"b":"B"}}}
 `,
 		},
+		{
+			description: "with span context",
+			opts: []gcp.Option{
+				gcp.WithTrace("test"),
+				gcp.WithSpanContext(func(context.Context) gcp.TraceContext {
+					return gcp.TraceContext{
+						TraceID:    [16]byte{75, 249, 47, 53, 119, 179, 77, 166, 163, 206, 146, 157, 14, 14, 71, 54},
+						SpanID:     [8]byte{0, 240, 103, 170, 11, 169, 2, 183},
+						TraceFlags: 1,
+						TraceState: "congo=t61rcWkgMzE",
+						Remote:     true,
+					}
+				}),
+			},
+			expected: `{"timestamp":{"seconds":100,"nanos":1000},"severity":"INFO","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":43},"message":"info","a":"A","logging.googleapis.com/trace":"projects/test/traces/4bf92f3577b34da6a3ce929d0e0e4736","logging.googleapis.com/spanId":"00f067aa0ba902b7","logging.googleapis.com/trace_sampled":true}
+{"timestamp":{"seconds":100,"nanos":1000},"severity":"WARNING","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":48},"message":"warn","logging.googleapis.com/trace":"projects/test/traces/4bf92f3577b34da6a3ce929d0e0e4736","logging.googleapis.com/spanId":"00f067aa0ba902b7","logging.googleapis.com/trace_sampled":true,"g":{"b":"B","a":"A"}}
+{"timestamp":{"seconds":100,"nanos":1000},"severity":"ERROR","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":56},"message":"error","logging.googleapis.com/trace":"projects/test/traces/4bf92f3577b34da6a3ce929d0e0e4736","logging.googleapis.com/spanId":"00f067aa0ba902b7","logging.googleapis.com/trace_sampled":true,"g":{"h":{"b":"B"}}}
+`,
+		},
+		{
+			description: "with error reporting (without version)",
+			opts: []gcp.Option{
+				gcp.WithErrorReporting("test", ""),
+			},
+			err: errors.New("an error"),
+			expected: `{"timestamp":{"seconds":100,"nanos":1000},"severity":"INFO","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":43},"message":"info","a":"A","trace_id":"4bf92f3577b34da6a3ce929d0e0e4736","span_id":"00f067aa0ba902b7","trace_flags":"01"}
+{"timestamp":{"seconds":100,"nanos":1000},"severity":"WARNING","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":48},"message":"warn","g":{"b":"B","a":"A"}}
+{"timestamp":{"seconds":100,"nanos":1000},"severity":"ERROR","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":56},"message":"error","context":{"reportLocation":{"filePath":"/handler_test.go","lineNumber":56,"functionName":"github.com/nil-go/sloth/gcp_test.TestHandler.func1"}},"serviceContext":{"service":"test"},"stack_trace":"error\n\n\ngithub.com/nil-go/sloth/gcp_test.TestHandler.func1()\n\t/handler_test.go:56"g":{"h":{"b":"B","error":"an error"}}}
+`,
+		},
+		{
+			description: "with trace (otel format)",
+			opts: []gcp.Option{
+				gcp.WithTrace("test"),
+				gcp.WithTraceFormat(gcp.TraceFormatOTel),
+			},
+			expected: `{"timestamp":{"seconds":100,"nanos":1000},"severity":"INFO","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":43},"message":"info","a":"A","span_context":{"trace_id":"4bf92f3577b34da6a3ce929d0e0e4736","span_id":"00f067aa0ba902b7","trace_flags":"01"}}
+{"timestamp":{"seconds":100,"nanos":1000},"severity":"WARNING","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":48},"message":"warn","g":{"b":"B","a":"A"}}
+{"timestamp":{"seconds":100,"nanos":1000},"severity":"ERROR","logging.googleapis.com/sourceLocation":{"function":"github.com/nil-go/sloth/gcp_test.TestHandler.func1","file":"/handler_test.go","line":56},"message":"error","g":{"h":{"b":"B"}}}
+`,
+		},
+	}
+}
+
+func TestHandler_levelVar(t *testing.T) {
+	t.Parallel()
+
+	level := &slog.LevelVar{}
+	buf := &bytes.Buffer{}
+	logger := slog.New(gcp.New(
+		gcp.WithWriter(buf),
+		gcp.WithLevel(level),
+		gcp.WithErrorReporting("test", "dev"),
+	))
+	logger.Info("info before")
+	level.Set(slog.LevelError)
+	logger.Info("info after")
+	assert.Equal(t, false, logger.Enabled(context.Background(), slog.LevelWarn))
+
+	assert.Equal(t, 1, strings.Count(buf.String(), "\n"))
+	assert.Equal(t, true, strings.Contains(buf.String(), `"message":"info before"`))
+}
+
+func TestHandler_close(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	writer := &closeWriter{Writer: bufio.NewWriter(buf)}
+	handler := gcp.New(gcp.WithWriter(writer), gcp.WithErrorReporting("test", "dev"), gcp.WithCloseWriter(true))
+	slog.New(handler).Info("info")
+	assert.Equal(t, "", buf.String())
+
+	closer, ok := handler.WithGroup("g").(io.Closer)
+	assert.Equal(t, true, ok)
+	assert.NoError(t, closer.Close())
+	assert.Equal(t, true, strings.Contains(buf.String(), `"message":"info"`))
+	assert.Equal(t, true, writer.closed)
+
+	_, ok = gcp.New(gcp.WithWriter(&bytes.Buffer{})).(io.Closer)
+	assert.Equal(t, false, ok)
+	_, ok = gcp.New(gcp.WithWriter(os.Stderr)).(io.Closer)
+	assert.Equal(t, false, ok)
+}
+
+func TestHandler_closeWithoutCloseWriter(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	writer := &closeWriter{Writer: bufio.NewWriter(buf)}
+	handler := gcp.New(gcp.WithWriter(writer))
+	slog.New(handler).Info("info")
+
+	closer, ok := handler.(io.Closer)
+	assert.Equal(t, true, ok)
+	assert.NoError(t, closer.Close())
+	assert.Equal(t, true, strings.Contains(buf.String(), `"message":"info"`))
+	assert.Equal(t, false, writer.closed)
+}
+
+type closeWriter struct {
+	*bufio.Writer
+
+	closed bool
+}
+
+func (w *closeWriter) Close() error {
+	w.closed = true
+
+	return nil
+}
+
+func TestHandler_nestedError(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	logger := slog.New(gcp.New(gcp.WithWriter(buf), gcp.WithErrorReporting("test", "dev")))
+	logger.Error("error", slog.Group("g", slog.Group("h", "error", stackError{errors.New("an error")})))
+
+	// The stack trace derives from the callers of the error rather than the call site.
+	assert.Equal(t, true, strings.Contains(buf.String(), `gcp_test.stackError.Callers()`))
+	assert.Equal(t, true, strings.Contains(buf.String(), `"g":{"h":{"error":"an error"}}`))
+}
+
+func TestHandler_traceLogValuer(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		attr        slog.Attr
+	}{
+		{
+			description: "trace id",
+			attr:        slog.Any(gcp.TraceKey, traceValuer{}),
+		},
+		{
+			description: "inlined group",
+			attr:        slog.Any("", traceValuer{group: true}),
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.description, func(t *testing.T) {
+			t.Parallel()
+
+			buf := &bytes.Buffer{}
+			logger := slog.New(gcp.New(
+				gcp.WithWriter(buf),
+				gcp.WithTrace("test"),
+				gcp.WithTraceContext(func(context.Context) ([16]byte, [8]byte, byte) {
+					return [16]byte{1}, [8]byte{1}, 1
+				}),
+			))
+			logger.Info("info", testcase.attr)
+			logger.With(testcase.attr).Info("info")
+
+			assert.Equal(t, 2, strings.Count(buf.String(),
+				`"logging.googleapis.com/trace":"projects/test/traces/4bf92f3577b34da6a3ce929d0e0e4736"`))
+			assert.Equal(t, false, strings.Contains(buf.String(), "01000000000000000000000000000000"))
+		})
+	}
+}
+
+type traceValuer struct {
+	group bool
+}
+
+func (v traceValuer) LogValue() slog.Value {
+	if v.group {
+		return slog.GroupValue(slog.String(gcp.TraceKey, "4bf92f3577b34da6a3ce929d0e0e4736"))
+	}
+
+	return slog.StringValue("4bf92f3577b34da6a3ce929d0e0e4736")
+}
+
+func TestHandler_goroutineID(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	logger := slog.New(gcp.New(
+		gcp.WithWriter(buf),
+		gcp.WithErrorReporting("test", "dev"),
+		gcp.WithGoroutineID(func(context.Context) uint64 { return 42 }),
+	))
+	logger.Error("error")
+
+	assert.Equal(t, true, strings.Contains(buf.String(), `"stack_trace":"error\n\ngoroutine 42 [running]:\n`))
+}
+
+func TestHandler_structuredError(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	logger := slog.New(gcp.New(gcp.WithWriter(buf), gcp.WithStructuredError(true)))
+	err := fmt.Errorf("query: %w", errors.Join(errors.New("timeout"), stackError{errors.New("canceled")}))
+	logger.Warn("warn", "error", err)
+	logger.Error("error", "error", err)
+
+	log := buf.String()
+	assert.Equal(t, 1, strings.Count(log, gcp.ErrorChainKey))
+	assert.Equal(t, true, strings.Contains(log, `"error_chain":[`+
+		`{"type":"*fmt.wrapError","message":"query: timeout\ncanceled"},`+
+		`{"type":"*errors.joinError","message":"timeout\ncanceled"},`+
+		`{"type":"*errors.errorString","message":"timeout"},`+
+		`{"type":"gcp_test.stackError","message":"canceled"}]`))
+}
+
+func BenchmarkHandler_trace(b *testing.B) {
+	// The trace-enabled handler should have the same allocations as the bare handler
+	// if there is no trace in the context.
+	handlers := map[string]slog.Handler{
+		"bare": gcp.New(gcp.WithWriter(io.Discard)),
+		"trace": gcp.New(
+			gcp.WithWriter(io.Discard),
+			gcp.WithTrace("test"),
+			gcp.WithTraceContext(func(context.Context) ([16]byte, [8]byte, byte) {
+				return [16]byte{}, [8]byte{}, 0
+			}),
+		),
+	}
+	ctx := context.Background()
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, "info", 0)
+	record.AddAttrs(slog.String("a", "A"))
+
+	for name, handler := range handlers {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				_ = handler.Handle(ctx, record)
+			}
+		})
+	}
+}
+
+func TestHandler_traceFormat(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	logger := slog.New(gcp.New(
+		gcp.WithWriter(buf),
+		gcp.WithTrace("test"),
+		gcp.WithTraceFormat(gcp.TraceFormatOTel),
+		gcp.WithTraceContext(func(context.Context) ([16]byte, [8]byte, byte) {
+			return [16]byte{75, 249, 47, 53, 119, 179, 77, 166, 163, 206, 146, 157, 14, 14, 71, 54},
+				[8]byte{0, 240, 103, 170, 11, 169, 2, 183},
+				1
+		}),
+	))
+	logger.Info("context")
+	logger.Info("record", "a", "A", gcp.TraceKey, "01000000000000000000000000000000", gcp.SpanKey, "0100000000000000")
+
+	assert.Equal(t, true, strings.Contains(buf.String(), `"message":"context","span_context":{`+
+		`"trace_id":"4bf92f3577b34da6a3ce929d0e0e4736","span_id":"00f067aa0ba902b7","trace_flags":"01"}}`))
+	assert.Equal(t, true, strings.Contains(buf.String(), `"message":"record","a":"A","span_context":{`+
+		`"trace_id":"01000000000000000000000000000000","span_id":"0100000000000000"}}`))
+}
+
+func TestHandler_promotedKeys(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	logger := slog.New(gcp.New(gcp.WithWriter(buf), gcp.WithPromotedKeys("request_id", "tenant", "missing")))
+	logger.With("tenant", "t<1>").Info("info", "a", "A", "request_id", "r1", "tenant", "t<2>")
+
+	// Only the first occurrence of the duplicate key is promoted.
+	assert.Equal(t, true, strings.HasPrefix(buf.String(), `{"request_id":"r1","tenant":"t<1>","timestamp":{`))
+	assert.Equal(t, true, strings.HasSuffix(buf.String(), `"message":"info","a":"A","tenant":"t<2>"}`+"\n"))
+}
+
+func TestHandler_emptyGroup(t *testing.T) {
+	t.Parallel()
+
+	buf1, buf2 := &bytes.Buffer{}, &bytes.Buffer{}
+	opts := []gcp.Option{
+		gcp.WithTrace("test"),
+		gcp.WithTraceContext(func(context.Context) ([16]byte, [8]byte, byte) {
+			return [16]byte{1}, [8]byte{1}, 1
+		}),
+	}
+	attrs := []slog.Attr{slog.String("a", "A")}
+	record := slog.NewRecord(time.Unix(100, 0), slog.LevelInfo, "msg", 0)
+	record.AddAttrs(slog.String(gcp.TraceKey, "4bf92f3577b34da6a3ce929d0e0e4736"))
+	assert.NoError(t, gcp.New(append(opts, gcp.WithWriter(buf1))...).
+		WithGroup("").WithAttrs(attrs).Handle(context.Background(), record))
+	assert.NoError(t, gcp.New(append(opts, gcp.WithWriter(buf2))...).
+		WithAttrs(attrs).Handle(context.Background(), record))
+
+	assert.Equal(t, buf2.String(), buf1.String())
+}
+
+func TestHandler_traceFlags(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		flags       string
+		sampled     bool
+	}{
+		{
+			description: "one digit",
+			flags:       "1",
+			sampled:     true,
+		},
+		{
+			description: "two digits",
+			flags:       "01",
+			sampled:     true,
+		},
+		{
+			description: "raw byte",
+			flags:       "\x01",
+			sampled:     true,
+		},
+		{
+			description: "unsampled",
+			flags:       "00",
+		},
+		{
+			description: "garbage",
+			flags:       "garbage",
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.description, func(t *testing.T) {
+			t.Parallel()
+
+			buf := &bytes.Buffer{}
+			logger := slog.New(gcp.New(gcp.WithWriter(buf), gcp.WithTrace("test")))
+			logger.Info("info", gcp.TraceFlagsKey, testcase.flags)
+
+			assert.Equal(t, true, strings.Contains(buf.String(),
+				fmt.Sprintf(`"logging.googleapis.com/trace_sampled":%t`, testcase.sampled)))
+		})
+	}
+}
+
+func TestHandler_innerHandler(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	handler := gcp.New(
+		gcp.WithWriter(buf),
+		gcp.WithTrace("test"),
+		gcp.WithInnerHandler(func(writer io.Writer, opts *slog.HandlerOptions) slog.Handler {
+			opts.AddSource = false
+
+			return slog.NewTextHandler(writer, opts)
+		}),
+	)
+	assert.NoError(t, handler.Handle(context.Background(), record(slog.LevelWarn, "warn",
+		gcp.TraceKey, "4bf92f3577b34da6a3ce929d0e0e4736",
+	)))
+
+	assert.Equal(t, true, strings.Contains(buf.String(), "severity=WARNING message=warn"))
+	assert.Equal(t, true, strings.Contains(buf.String(),
+		"logging.googleapis.com/trace=projects/test/traces/4bf92f3577b34da6a3ce929d0e0e4736"))
+}
+
+func TestHandler_innerHandlerPanic(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		assert.Equal(t, "cannot create Handler with nil inner handler", recover().(string))
+	}()
+
+	gcp.New(gcp.WithInnerHandler(func(io.Writer, *slog.HandlerOptions) slog.Handler { return nil }))
+	t.Fail()
+}
+
+func TestHandler_logName(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	handler := gcp.New(
+		gcp.WithWriter(buf),
+		gcp.WithLogName(func(ctx context.Context) string {
+			name, _ := ctx.Value(logNameKey{}).(string)
+
+			return name
+		}),
+	)
+	ctx := context.WithValue(context.Background(), logNameKey{}, "projects/test/logs/audit")
+	assert.NoError(t, handler.Handle(ctx, record(slog.LevelInfo, "audit")))
+	assert.NoError(t, handler.Handle(context.Background(), record(slog.LevelInfo, "app")))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, 2, len(lines))
+	assert.Equal(t, true, strings.Contains(lines[0], `"logName":"projects/test/logs/audit"`))
+	assert.Equal(t, false, strings.Contains(lines[1], `"logName":`))
+}
+
+type logNameKey struct{}
+
+func TestHandler_reportLocationWithoutSource(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	handler := gcp.New(
+		gcp.WithWriter(buf),
+		gcp.WithErrorReporting("test", "dev"),
+		gcp.WithInnerHandler(func(writer io.Writer, opts *slog.HandlerOptions) slog.Handler {
+			opts.AddSource = false
+
+			return slog.NewJSONHandler(writer, opts)
+		}),
+	)
+	var pcs [1]uintptr
+	runtime.Callers(1, pcs[:])
+	frame, _ := runtime.CallersFrames(pcs[:]).Next()
+	record := slog.NewRecord(time.Unix(100, 1000), slog.LevelError, "error", pcs[0])
+	assert.NoError(t, handler.Handle(context.Background(), record))
+
+	var entry struct {
+		Context struct {
+			ReportLocation struct {
+				FilePath     string `json:"filePath"`
+				LineNumber   int    `json:"lineNumber"`
+				FunctionName string `json:"functionName"`
+			} `json:"reportLocation"`
+		} `json:"context"`
+		SourceLocation any `json:"logging.googleapis.com/sourceLocation"`
+	}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, nil, entry.SourceLocation)
+	assert.Equal(t, frame.File, entry.Context.ReportLocation.FilePath)
+	assert.Equal(t, frame.Line, entry.Context.ReportLocation.LineNumber)
+	assert.Equal(t, frame.Function, entry.Context.ReportLocation.FunctionName)
+}
+
+func TestHandler_writers(t *testing.T) {
+	t.Parallel()
+
+	buf1, buf2 := &bytes.Buffer{}, &bytes.Buffer{}
+	logger := slog.New(gcp.New(gcp.WithWriters(buf1, failWriter{}, buf2)))
+	logger.Info("info", "a", "A")
+	logger.Warn("warn")
+
+	assert.Equal(t, 2, strings.Count(buf1.String(), "\n"))
+	assert.Equal(t, buf1.String(), buf2.String())
+}
+
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestHandler_syntheticTrace(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	logger := slog.New(gcp.New(
+		gcp.WithWriter(buf),
+		gcp.WithTrace("test"),
+		gcp.WithTraceContext(func(context.Context) ([16]byte, [8]byte, byte) {
+			return [16]byte{}, [8]byte{}, 0
+		}),
+		gcp.WithSyntheticTrace(true),
+	))
+	ctx := gcp.WithSyntheticTraceContext(context.Background())
+	logger.InfoContext(ctx, "first")
+	// The trace id is kept for the same request.
+	ctx = gcp.WithSyntheticTraceContext(context.WithValue(ctx, logNameKey{}, "value"))
+	logger.With("a", "A").InfoContext(ctx, "second")
+	// Requests sharing the same cancelable parent have their own trace ids.
+	parent, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	logger.InfoContext(gcp.WithSyntheticTraceContext(parent), "other")
+	logger.InfoContext(parent, "without synthetic trace")
+
+	var traces []string
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var entry struct {
+			Trace string `json:"logging.googleapis.com/trace"`
+		}
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		traces = append(traces, entry.Trace)
+	}
+	assert.Equal(t, 4, len(traces))
+	assert.Equal(t, true, strings.HasPrefix(traces[0], "projects/test/traces/"))
+	assert.Equal(t, traces[0], traces[1])
+	assert.Equal(t, true, strings.HasPrefix(traces[2], "projects/test/traces/"))
+	assert.Equal(t, false, traces[0] == traces[2])
+	assert.Equal(t, "", traces[3])
+}
+
+func TestHandler_stackFilter(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	logger := slog.New(gcp.New(
+		gcp.WithWriter(buf),
+		gcp.WithErrorReporting("test", "dev"),
+		gcp.WithCallers(func(error) []uintptr {
+			// Capture callers inside the handler, so the stack has frames of log/slog and the gcp package.
+			var pcs [32]uintptr
+			count := runtime.Callers(1, pcs[:])
+
+			return pcs[:count]
+		}),
+		gcp.WithStackFilter(gcp.DefaultStackFilter),
+	))
+	logger.Error("error", "error", errors.New("an error"))
+
+	var entry struct {
+		StackTrace string `json:"stack_trace"`
+	}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, false, strings.Contains(entry.StackTrace, "log/slog."))
+	assert.Equal(t, false, strings.Contains(entry.StackTrace, "github.com/nil-go/sloth/"))
+	assert.Equal(t, true, strings.Contains(entry.StackTrace, "testing.tRunner"))
+}
+
+func TestHandler_errorKey(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		key         string
+		stackError  bool
+	}{
+		{
+			description: "first error",
+		},
+		{
+			description: "with error key",
+			key:         "error",
+			stackError:  true,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.description, func(t *testing.T) {
+			t.Parallel()
+
+			buf := &bytes.Buffer{}
+			logger := slog.New(gcp.New(
+				gcp.WithWriter(buf),
+				gcp.WithErrorReporting("test", "dev"),
+				gcp.WithErrorKey(testcase.key),
+			))
+			logger.Error("error", "cause", errors.New("a cause"), "error", stackError{errors.New("an error")})
+
+			assert.Equal(t, testcase.stackError, strings.Contains(buf.String(), `gcp_test.stackError.Callers()`))
+		})
+	}
+}
+
+func TestHandler_traceGroup(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	logger := slog.New(gcp.New(
+		gcp.WithWriter(buf),
+		gcp.WithTrace("test"),
+		gcp.WithTraceContext(func(context.Context) ([16]byte, [8]byte, byte) {
+			return [16]byte{1}, [8]byte{1}, 0
+		}),
+		gcp.WithTraceGroup("trace", "id", "span", "flags"),
+	))
+	trace := slog.Group("trace",
+		"id", "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span", "00f067aa0ba902b7",
+		"flags", "01",
+		"state", "vendor=value",
+	)
+	logger.Info("info", trace)
+	logger.With(trace).Info("info")
+
+	assert.Equal(t, 2, strings.Count(buf.String(), "\n"))
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		line := scanner.Text()
+		assert.Equal(t, true, strings.Contains(line,
+			`"logging.googleapis.com/trace":"projects/test/traces/4bf92f3577b34da6a3ce929d0e0e4736",`+
+				`"logging.googleapis.com/spanId":"00f067aa0ba902b7",`+
+				`"logging.googleapis.com/trace_sampled":true,"trace":{"state":"vendor=value"}`))
+		assert.Equal(t, false, strings.Contains(line, "01000000000000000000000000000000"))
+	}
+}
+
+func TestHandler_fieldNames(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	handler := gcp.New(
+		gcp.WithWriter(buf),
+		gcp.WithFieldNames(map[string]string{"message": "msg", "severity": "level", "unknown": "ignored"}),
+	)
+	assert.NoError(t, handler.Handle(context.Background(), record(slog.LevelInfo, "info")))
+
+	var entry map[string]any
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "info", entry["msg"])
+	assert.Equal(t, "INFO", entry["level"])
+	assert.Equal(t, nil, entry["message"])
+	assert.Equal(t, nil, entry["severity"])
+	assert.Equal(t, true, entry["timestamp"] != nil)
+}
+
+func TestHandler_traceID(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		traceID     string
+		expected    string
+	}{
+		{
+			description: "valid",
+			traceID:     "4bf92f3577b34da6a3ce929d0e0e4736",
+			expected:    `"logging.googleapis.com/trace":"projects/test/traces/4bf92f3577b34da6a3ce929d0e0e4736"`,
+		},
+		{
+			description: "short",
+			traceID:     "f92f3577b34da6a3ce929d0e0e4736",
+			expected:    `"logging.googleapis.com/trace":"projects/test/traces/00f92f3577b34da6a3ce929d0e0e4736"`,
+		},
+		{
+			description: "long",
+			traceID:     "FF4bf92f3577b34da6a3ce929d0e0e4736",
+			expected:    `"logging.googleapis.com/trace":"projects/test/traces/4bf92f3577b34da6a3ce929d0e0e4736"`,
+		},
+		{
+			description: "invalid",
+			traceID:     "not a trace id",
+			expected:    `"trace_id":"not a trace id"`,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.description, func(t *testing.T) {
+			t.Parallel()
+
+			buf := &bytes.Buffer{}
+			handler := gcp.New(gcp.WithWriter(buf), gcp.WithTrace("test"))
+			assert.NoError(t, handler.Handle(context.Background(), record(slog.LevelInfo, "info",
+				gcp.TraceKey, testcase.traceID,
+			)))
+
+			assert.Equal(t, true, strings.Contains(buf.String(), testcase.expected))
+		})
+	}
+}
+
+func TestHandler_leveler(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	handler := gcp.New(
+		gcp.WithWriter(buf),
+		gcp.WithInnerHandler(func(writer io.Writer, opts *slog.HandlerOptions) slog.Handler {
+			replaceAttr := opts.ReplaceAttr
+			opts.ReplaceAttr = func(groups []string, attr slog.Attr) slog.Attr {
+				if len(groups) == 0 && attr.Key == slog.LevelKey {
+					attr.Value = slog.AnyValue(customLevel("warning"))
+				}
+
+				return replaceAttr(groups, attr)
+			}
+
+			return slog.NewJSONHandler(writer, opts)
+		}),
+	)
+	assert.NoError(t, handler.Handle(context.Background(), record(slog.LevelInfo, "info")))
+
+	assert.Equal(t, true, strings.Contains(buf.String(), `"severity":"WARNING"`))
+}
+
+type customLevel string
+
+func (l customLevel) Level() slog.Level {
+	if l == "warning" {
+		return slog.LevelWarn
 	}
+
+	return slog.LevelInfo
+}
+
+func TestHandler_sourceLocationFields(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	handler := gcp.New(
+		gcp.WithWriter(buf),
+		gcp.WithSourceLocationFields(gcp.SourceFunction|gcp.SourceLine),
+	)
+	assert.NoError(t, handler.Handle(context.Background(), record(slog.LevelInfo, "info")))
+
+	var entry map[string]any
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	source, _ := entry["logging.googleapis.com/sourceLocation"].(map[string]any)
+	assert.Equal(t, "github.com/nil-go/sloth/gcp_test.TestHandler_sourceLocationFields", source["function"])
+	assert.Equal(t, true, source["line"] != nil)
+	_, hasFile := source["file"]
+	assert.Equal(t, false, hasFile)
 }
+
+func TestHandler_traceAllocs(t *testing.T) {
+	// The trace-enabled handler should have the same allocations as the bare handler
+	// if there is no trace in the context.
+	bare := gcp.New(gcp.WithWriter(io.Discard))
+	traced := gcp.New(
+		gcp.WithWriter(io.Discard),
+		gcp.WithTrace("test"),
+		gcp.WithTraceContext(func(context.Context) ([16]byte, [8]byte, byte) {
+			return [16]byte{}, [8]byte{}, 0
+		}),
+	)
+	ctx := context.Background()
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, "info", 0)
+	record.AddAttrs(slog.String("a", "A"))
+
+	expected := testing.AllocsPerRun(100, func() { _ = bare.Handle(ctx, record) })
+	actual := testing.AllocsPerRun(100, func() { _ = traced.Handle(ctx, record) })
+	assert.Equal(t, expected, actual)
+}
+
+func TestHandler_writersClose(t *testing.T) {
+	t.Parallel()
+
+	buf1, buf2 := &bytes.Buffer{}, &bytes.Buffer{}
+	writer1 := &closeWriter{Writer: bufio.NewWriter(buf1)}
+	writer2 := &closeWriter{Writer: bufio.NewWriter(buf2)}
+	handler := gcp.New(gcp.WithWriters(writer1, writer2), gcp.WithCloseWriter(true))
+	slog.New(handler).Info("info")
+	assert.Equal(t, "", buf1.String())
+
+	closer, ok := handler.(io.Closer)
+	assert.Equal(t, true, ok)
+	assert.NoError(t, closer.Close())
+	assert.Equal(t, true, strings.Contains(buf1.String(), `"message":"info"`))
+	assert.Equal(t, buf1.String(), buf2.String())
+	assert.Equal(t, true, writer1.closed)
+	assert.Equal(t, true, writer2.closed)
+}
+
+func TestHandler_promotedKeysConcurrent(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	handler := gcp.New(
+		gcp.WithWriter(buf),
+		gcp.WithPromotedKeys("request_id"),
+		gcp.WithInnerHandler(func(writer io.Writer, _ *slog.HandlerOptions) slog.Handler {
+			return unlockedHandler{writer: writer}
+		}),
+	)
+
+	const goroutines, records = 8, 100
+	done := make(chan struct{})
+	for range goroutines {
+		go func() {
+			for range records {
+				_ = handler.Handle(context.Background(), record(slog.LevelInfo, "info"))
+			}
+			done <- struct{}{}
+		}()
+	}
+	for range goroutines {
+		<-done
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	assert.Equal(t, goroutines*records, len(lines))
+	for _, line := range lines {
+		assert.Equal(t, `{"request_id":"r1","message":"info"}`, line)
+	}
+}
+
+// unlockedHandler writes each record to the writer without a lock.
+type unlockedHandler struct {
+	writer io.Writer
+}
+
+func (unlockedHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h unlockedHandler) Handle(_ context.Context, record slog.Record) error {
+	_, err := fmt.Fprintf(h.writer, `{"message":%q,"request_id":"r1"}`+"\n", record.Message)
+
+	return err
+}
+
+func (h unlockedHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h unlockedHandler) WithGroup(string) slog.Handler { return h }
@@ -7,10 +7,13 @@ import (
 	"context"
 	"io"
 	"log/slog"
+	"maps"
+	"runtime"
 )
 
 // WithLevel provides the minimum record level that will be logged.
 // The handler discards records with lower levels.
+// The level could be changed at runtime if it's a *slog.LevelVar.
 //
 // If Level is nil, the handler assumes LevelInfo.
 func WithLevel(level slog.Leveler) Option {
@@ -28,8 +31,69 @@ func WithWriter(writer io.Writer) Option {
 	}
 }
 
+// WithWriters provides multiple writers to which the handler writes the same log entries,
+// e.g. both os.Stderr and a file for local debugging. Errors of writers are aggregated,
+// and a failed writer does not prevent writing to others.
+// It overrides WithWriter, and it's equivalent to WithWriter if there is only one writer.
+func WithWriters(writers ...io.Writer) Option {
+	return func(options *options) {
+		switch len(writers) {
+		case 0:
+			options.writer = nil
+		case 1:
+			options.writer = writers[0]
+		default:
+			options.writer = newTeeWriter(writers)
+		}
+	}
+}
+
+// WithCloseWriter closes the writer provided by WithWriter or WithWriters if it implements io.Closer
+// when the handler is closed, e.g. a file opened only for logging.
+//
+// By default, the handler only flushes the writer on Close since it does not own the writer, e.g. os.Stderr.
+func WithCloseWriter(closeWriter bool) Option {
+	return func(options *options) {
+		options.closeWriter = closeWriter
+	}
+}
+
+// WithInnerHandler provides the function to create the handler which encodes records to the writer,
+// e.g. a faster JSON encoder. The handler must respect the given slog.HandlerOptions,
+// so the GCP format, trace and error reporting still apply. It panics if the function returns nil.
+//
+// If it is nil, the handler assumes slog.NewJSONHandler.
+func WithInnerHandler(handler func(io.Writer, *slog.HandlerOptions) slog.Handler) Option {
+	return func(options *options) {
+		options.innerHandler = handler
+	}
+}
+
+// WithFieldNames overrides names of special fields emitted by the handler, e.g. "message" to "msg",
+// for tooling which post-processes logs. The keys of the map are default names of special fields:
+// "message", "severity", "timestamp" and "logging.googleapis.com/sourceLocation". Other keys are ignored.
+//
+// Note that GCP Cloud Logging does not recognize special fields with overridden names.
+func WithFieldNames(names map[string]string) Option {
+	return func(options *options) {
+		options.fieldNames = maps.Clone(names)
+	}
+}
+
+// WithPromotedKeys promotes attributes with the given keys to the top of the log entry in the given order,
+// e.g. business fields like tenant and request_id, so they are more visible in Cloud Logging.
+// Only attributes at the root of the log entry could be promoted,
+// and only the first occurrence is promoted if the key appears more than once.
+//
+// It has performance cost since the handler has to decode and re-encode each log entry.
+func WithPromotedKeys(keys ...string) Option {
+	return func(options *options) {
+		options.promotedKeys = append(options.promotedKeys, keys...)
+	}
+}
+
 // WithTrace enables [trace information] added to the log for [GCP Cloud Trace] integration.
-// The handler use function set in WithTraceContext to get trace information
+// The handler use function set in WithSpanContext or WithTraceContext to get trace information
 // if it does not present in record's attributes yet.
 //
 // [trace information]: https://cloud.google.com/trace/docs/trace-log-integration
@@ -41,17 +105,79 @@ func WithTrace(project string) Option {
 }
 
 // WithTraceContext providers the [W3C Trace Context] while WithTrace has been called.
+// It is superseded by WithSpanContext, and the last one set takes effect.
 //
 // If it is nil, the handler finds trace information from record's attributes.
 //
 // [W3C Trace Context]: https://www.w3.org/TR/trace-context/#traceparent-header-field-values
 func WithTraceContext(provider func(context.Context) (traceID [16]byte, spanID [8]byte, traceFlags byte)) Option {
+	return func(options *options) {
+		if provider == nil {
+			options.contextProvider = nil
+
+			return
+		}
+
+		options.contextProvider = func(ctx context.Context) TraceContext {
+			traceID, spanID, traceFlags := provider(ctx)
+
+			return TraceContext{TraceID: traceID, SpanID: spanID, TraceFlags: traceFlags}
+		}
+	}
+}
+
+// WithSpanContext providers the TraceContext of the span while WithTrace has been called.
+// It supersedes WithTraceContext, and the last one set takes effect.
+//
+// If it is nil, the handler finds trace information from record's attributes.
+func WithSpanContext(provider func(context.Context) TraceContext) Option {
 	return func(options *options) {
 		options.contextProvider = provider
 	}
 }
 
+// WithSyntheticTrace adds the random trace id generated by WithSyntheticTraceContext for each request
+// while WithTrace has been called, if there is no valid trace in the context,
+// so all logs of the request are still correlated.
+// The log entry has no synthetic trace if the context is not from WithSyntheticTraceContext.
+func WithSyntheticTrace(syntheticTrace bool) Option {
+	return func(options *options) {
+		options.syntheticTrace = syntheticTrace
+	}
+}
+
+// WithTraceGroup recognizes trace information provided as the group attribute with the given name
+// while WithTrace has been called, e.g. `slog.Group("trace", "id", traceID, "span", spanID, "flags", "01")`,
+// and remaps the attributes with the given keys to trace attributes, e.g. TraceKey.
+// Only the group at the root of the log entry is recognized.
+func WithTraceGroup(name, idKey, spanKey, flagsKey string) Option {
+	return func(options *options) {
+		options.traceGroup = &traceGroup{name: name, idKey: idKey, spanKey: spanKey, flagsKey: flagsKey}
+	}
+}
+
+// WithTraceFormat provides the format of trace information while WithTrace has been called.
+//
+// By default, the handler emits trace information in TraceFormatGCP.
+func WithTraceFormat(format TraceFormat) Option {
+	return func(options *options) {
+		options.traceFormat = format
+	}
+}
+
+// WithSourceLocationFields provides fields kept in the source location of the log entry,
+// e.g. SourceFunction|SourceLine for omitting the absolute path of the source file for privacy and size.
+// It does not affect the report location of error events.
+//
+// By default, the handler keeps all fields: SourceFile|SourceLine|SourceFunction.
+func WithSourceLocationFields(fields SourceField) Option {
+	return func(options *options) {
+		options.sourceFields = fields
+	}
+}
+
 // WithErrorReporting enables logs reported as [error events] to [GCP Error Reporting].
+// The version is omitted from the service context if it's empty.
 //
 // [error events]: https://cloud.google.com/error-reporting/docs/formatting-error-messages
 // [GCP Error Reporting]: https://cloud.google.com/error-reporting
@@ -73,20 +199,90 @@ func WithCallers(callers func(error) []uintptr) Option {
 	}
 }
 
+// WithStackFilter provides a function to filter frames of the stack trace while WithErrorReporting has been called,
+// e.g. DefaultStackFilter which drops frames of log/slog and sloth packages, so Error Reporting groups errors
+// by frames of the application. Frames are dropped if the function returns false.
+//
+// If it is nil, the handler keeps all frames.
+func WithStackFilter(filter func(runtime.Frame) bool) Option {
+	return func(options *options) {
+		options.stackFilter = filter
+	}
+}
+
+// WithErrorKey provides the key of the attribute which carries the error of the record
+// while WithErrorReporting or WithStructuredError has been called, e.g. the handled error
+// if the record also has the cause as another attribute. The error drives the stack trace and the error chain.
+//
+// If the key is empty, the handler uses the first error in the record.
+func WithErrorKey(key string) Option {
+	return func(options *options) {
+		options.errorKey = key
+	}
+}
+
+// WithGoroutineID provides a function to get the goroutine number in the stack trace
+// while WithErrorReporting has been called, e.g. a logical goroutine tag of the request in the context.
+//
+// If it is nil or returns 0, the handler uses 1 as the goroutine number.
+func WithGoroutineID(goroutineID func(context.Context) uint64) Option {
+	return func(options *options) {
+		options.goroutineID = goroutineID
+	}
+}
+
+// WithStructuredError serializes the first error of records with slog.LevelError and above
+// as the chain of errors unwrapped from it with their type names and messages,
+// under the key ErrorChainKey, e.g.
+//
+//	"error_chain": [{"type": "*fmt.wrapError", "message": "query: timeout"}, {"type": "*net.OpError", ...}]
+func WithStructuredError(structuredError bool) Option {
+	return func(options *options) {
+		options.structuredError = structuredError
+	}
+}
+
+// WithLogName provides a function to get the [log name] from the context, e.g. audit vs app,
+// which is added as a plain `logName` field of the log entry.
+// Logging agents do not route log entries on it, so the field ends up in jsonPayload as is,
+// and it's up to the consumer, e.g. a log router or sink filter, to use it.
+// The log entry omits the log name if the function returns empty string.
+//
+// [log name]: https://cloud.google.com/logging/docs/reference/v2/rest/v2/LogEntry#FIELDS.log_name
+func WithLogName(logName func(context.Context) string) Option {
+	return func(options *options) {
+		options.logName = logName
+	}
+}
+
 type (
 	// Option configures the Handler with specific options.
 	Option  func(*options)
 	options struct {
-		writer io.Writer
-		level  slog.Leveler
+		writer       io.Writer
+		level        slog.Leveler
+		promotedKeys []string
+		fieldNames   map[string]string
+		sourceFields SourceField
+		innerHandler func(io.Writer, *slog.HandlerOptions) slog.Handler
+		closeWriter  bool
 
 		// For trace.
 		project         string
-		contextProvider func(context.Context) (traceID [16]byte, spanID [8]byte, traceFlags byte)
+		contextProvider func(context.Context) TraceContext
+		traceFormat     TraceFormat
+		traceGroup      *traceGroup
+		syntheticTrace  bool
 
 		// For error reporting.
-		service string
-		version string
-		callers func(error) []uintptr
+		service     string
+		version     string
+		callers     func(error) []uintptr
+		stackFilter func(runtime.Frame) bool
+		goroutineID func(context.Context) uint64
+		errorKey    string
+
+		structuredError bool
+		logName         func(context.Context) string
 	}
 )
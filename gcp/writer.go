@@ -0,0 +1,162 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+package gcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"slices"
+	"sync"
+)
+
+var errNotObject = errors.New("log entry is not a JSON object")
+
+// promoteWriter reorders fields of each JSON log entry so promoted keys appear first.
+// If a key appears more than once in the entry, only its first occurrence is promoted
+// and the others are kept in their original positions.
+//
+// It has its own lock since the handler created by WithInnerHandler may not serialize writes.
+type promoteWriter struct {
+	writer io.Writer
+	keys   []string
+
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	fields []field
+}
+
+type field struct {
+	key      string
+	value    json.RawMessage
+	promoted bool
+}
+
+func (w *promoteWriter) Write(entry []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.reorder(entry); err != nil {
+		// Write the entry as is if it's not a JSON object.
+		return w.writer.Write(entry)
+	}
+
+	if _, err := w.writer.Write(w.buf.Bytes()); err != nil {
+		return 0, err
+	}
+
+	return len(entry), nil
+}
+
+func (w *promoteWriter) reorder(entry []byte) error {
+	w.buf.Reset()
+	w.fields = w.fields[:0]
+
+	decoder := json.NewDecoder(bytes.NewReader(entry))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return errNotObject
+	}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := token.(string)
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return err
+		}
+		w.fields = append(w.fields, field{key: key, value: value})
+	}
+
+	w.buf.WriteByte('{')
+	for _, key := range w.keys {
+		if i := slices.IndexFunc(w.fields, func(f field) bool { return f.key == key }); i >= 0 && !w.fields[i].promoted {
+			w.fields[i].promoted = true
+			w.writeField(w.fields[i])
+		}
+	}
+	for _, field := range w.fields {
+		if !field.promoted {
+			w.writeField(field)
+		}
+	}
+	w.buf.WriteString("}\n")
+
+	return nil
+}
+
+func (w *promoteWriter) writeField(field field) {
+	if w.buf.Len() > 1 {
+		w.buf.WriteByte(',')
+	}
+	key, _ := json.Marshal(field.key) //nolint:errchkjson // Marshaling string never fails.
+	w.buf.Write(key)
+	w.buf.WriteByte(':')
+	w.buf.Write(field.value)
+}
+
+// teeWriter writes each log entry to all writers, and aggregates errors of writers.
+// Each writer is guarded by its own lock, so the tee writer is safe for concurrent use.
+type teeWriter struct {
+	writers []lockedWriter
+}
+
+type lockedWriter struct {
+	mu     *sync.Mutex
+	writer io.Writer
+}
+
+func newTeeWriter(writers []io.Writer) *teeWriter {
+	tee := &teeWriter{writers: make([]lockedWriter, 0, len(writers))}
+	for _, writer := range writers {
+		tee.writers = append(tee.writers, lockedWriter{mu: &sync.Mutex{}, writer: writer})
+	}
+
+	return tee
+}
+
+func (w *teeWriter) Write(entry []byte) (int, error) {
+	var errs []error
+	for _, writer := range w.writers {
+		writer.mu.Lock()
+		n, err := writer.writer.Write(entry)
+		writer.mu.Unlock()
+		if err == nil && n < len(entry) {
+			err = io.ErrShortWrite
+		}
+		errs = append(errs, err)
+	}
+
+	return len(entry), errors.Join(errs...)
+}
+
+// Flush flushes writers which implement Flusher, and aggregates their errors.
+func (w *teeWriter) Flush() error {
+	var errs []error
+	for _, writer := range w.writers {
+		if flusher, ok := writer.writer.(Flusher); ok {
+			writer.mu.Lock()
+			errs = append(errs, flusher.Flush())
+			writer.mu.Unlock()
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Close closes writers which implement io.Closer, and aggregates their errors.
+func (w *teeWriter) Close() error {
+	var errs []error
+	for _, writer := range w.writers {
+		if closer, ok := writer.writer.(io.Closer); ok {
+			writer.mu.Lock()
+			errs = append(errs, closer.Close())
+			writer.mu.Unlock()
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+package jsonlog
+
+import (
+	"log/slog"
+	"slices"
+)
+
+type (
+	// Groups holds groups and their attributes added to a handler,
+	// so they could be replayed after attributes are added at the root of the log.
+	Groups []group
+	group  struct {
+		name  string
+		attrs []slog.Attr
+	}
+)
+
+// WithAttrs returns Groups with the attributes added to the last group.
+// It must not be called on empty Groups since attributes without a group belong to the root.
+func (g Groups) WithAttrs(attrs []slog.Attr) Groups {
+	g = slices.Clone(g)
+	g[len(g)-1].attrs = slices.Clone(g[len(g)-1].attrs)
+	g[len(g)-1].attrs = append(g[len(g)-1].attrs, attrs...)
+
+	return g
+}
+
+// WithGroup returns Groups with the group appended.
+// Empty group name is a no-op as required by slog.Handler.
+func (g Groups) WithGroup(name string) Groups {
+	if name == "" {
+		return g
+	}
+
+	g = slices.Clone(g)
+
+	return append(g, group{name: name})
+}
+
+// Replay adds the attributes to the handler at the root, and then replays the groups on it.
+func (g Groups) Replay(handler slog.Handler, attrs []slog.Attr) slog.Handler {
+	// Have to add the attributes to the handler before adding the group.
+	// Otherwise, the attributes are added to the group.
+	if len(attrs) > 0 {
+		// Avoid cloning the handler if there is no attribute to add, which is the common path.
+		handler = handler.WithAttrs(attrs)
+	}
+	for _, group := range g {
+		handler = handler.WithGroup(group.name).WithAttrs(group.attrs)
+	}
+
+	return handler
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+package jsonlog
+
+import (
+	"context"
+	"encoding/hex"
+	"log/slog"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// Handler adds trace and error details to records before passing them to the wrapped handler,
+// with keys which are mapped to platform specific keys by ReplaceAttr of the wrapped handler.
+type Handler struct {
+	Handler slog.Handler
+
+	// ContextProvider provides the trace context, which is added with TraceKey and SpanKey
+	// if the record does not have attribute TraceKey yet.
+	ContextProvider func(context.Context) (traceID [16]byte, spanID [8]byte, traceFlags byte)
+	TraceKey        string
+	SpanKey         string
+
+	// ErrorStack enables adding the type of the first error with ErrorTypeKey
+	// and the stack trace with StackKey to records with slog.LevelError and above.
+	ErrorStack   bool
+	Callers      func(error) []uintptr
+	ErrorTypeKey string
+	StackKey     string
+
+	hasTrace bool
+	groups   Groups
+}
+
+func (h Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.Handler.Enabled(ctx, level)
+}
+
+func (h Handler) Handle(ctx context.Context, record slog.Record) error {
+	var attrs []slog.Attr
+
+	if !h.hasTrace && h.ContextProvider != nil {
+		var found bool
+		// Only search for trace attributes if there are no groups.
+		if len(h.groups) == 0 {
+			record.Attrs(func(attr slog.Attr) bool {
+				found = attr.Key == h.TraceKey
+
+				return !found
+			})
+		}
+
+		if !found {
+			if traceID, spanID, _ := h.ContextProvider(ctx); traceID != [16]byte{} {
+				attrs = append(attrs,
+					slog.String(h.TraceKey, hex.EncodeToString(traceID[:])),
+					slog.String(h.SpanKey, hex.EncodeToString(spanID[:])),
+				)
+			}
+		}
+	}
+
+	if record.Level >= slog.LevelError && h.ErrorStack {
+		firstFrame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
+		var callers []uintptr
+		if err := RecordError(record, ""); err != nil {
+			attrs = append(attrs, slog.String(h.ErrorTypeKey, reflect.TypeOf(err).String()))
+			callers = h.Callers(err)
+		}
+
+		if len(callers) == 0 {
+			callers = Callers(firstFrame)
+		}
+		var stack strings.Builder
+		Stack(&stack, 1, callers, nil)
+		attrs = append(attrs, slog.String(h.StackKey, stack.String()))
+	}
+
+	return h.groups.Replay(h.Handler, attrs).Handle(ctx, record)
+}
+
+func (h Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(h.groups) == 0 {
+		h.Handler = h.Handler.WithAttrs(attrs)
+		if slices.ContainsFunc(attrs, func(attr slog.Attr) bool { return attr.Key == h.TraceKey }) {
+			h.hasTrace = true
+		}
+
+		return h
+	}
+
+	h.groups = h.groups.WithAttrs(attrs)
+
+	return h
+}
+
+func (h Handler) WithGroup(name string) slog.Handler {
+	h.groups = h.groups.WithGroup(name)
+
+	return h
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+// Package jsonlogtest provides test cases shared by handlers built on package jsonlog,
+// so tests of each handler only declare the expected log entries of its field mapping.
+package jsonlogtest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nil-go/sloth/internal/assert"
+)
+
+// TraceContext provides the trace context for handlers, e.g. WithTrace,
+// with trace id 4bf92f3577b34da6a3ce929d0e0e4736 and span id 00f067aa0ba902b7.
+func TraceContext(context.Context) ([16]byte, [8]byte, byte) {
+	return [16]byte{75, 249, 47, 53, 119, 179, 77, 166, 163, 206, 146, 157, 14, 14, 71, 54},
+		[8]byte{0, 240, 103, 170, 11, 169, 2, 183}, 1
+}
+
+// Run handles records of the shared test cases with the handler created with the writer,
+// and compares each log entry with the expected one keyed by the description of the test case:
+//   - info: the record with a group.
+//   - warn: the record with trace attributes `trace_id` and `span_id`.
+//   - error: the record with an error which has the stack trace.
+//   - custom level: the record with a level between standard levels.
+//
+// The directory of source files and the offset of stack frames are removed from log entries.
+func Run(t *testing.T, newHandler func(io.Writer) slog.Handler, expected map[string]string) {
+	t.Helper()
+
+	testcases := []struct {
+		description string
+		level       slog.Level
+		attrs       []any
+	}{
+		{
+			description: "info",
+			level:       slog.LevelInfo,
+			attrs:       []any{slog.Group("g", "a", "A")},
+		},
+		{
+			description: "warn",
+			level:       slog.LevelWarn,
+			attrs:       []any{"trace_id", "4bf92f3577b34da6a3ce929d0e0e4736", "span_id", "00f067aa0ba902b7"},
+		},
+		{
+			description: "error",
+			level:       slog.LevelError,
+			attrs:       []any{"error", stackError{errors.New("an error")}},
+		},
+		{
+			description: "custom level",
+			level:       slog.LevelInfo + 2,
+		},
+	}
+
+	_, file, _, _ := runtime.Caller(0)
+	dir := filepath.Dir(file)
+	for _, testcase := range testcases {
+		t.Run(testcase.description, func(t *testing.T) {
+			t.Parallel()
+
+			buf := &bytes.Buffer{}
+			handler := newHandler(buf)
+			assert.NoError(t, handler.Handle(context.Background(),
+				record(testcase.level, testcase.description, testcase.attrs...)))
+
+			log, after, found := strings.Cut(buf.String(), " +0x")
+			if found {
+				_, after, _ = strings.Cut(after, `"`)
+				log += `"` + after
+			}
+			assert.Equal(t, expected[testcase.description]+"\n", strings.ReplaceAll(log, dir, ""))
+		})
+	}
+}
+
+func record(level slog.Level, message string, attrs ...any) slog.Record {
+	var pcs [1]uintptr
+	runtime.Callers(2, pcs[:])
+
+	record := slog.NewRecord(time.Unix(100, 1000).UTC(), level, message, pcs[0])
+	record.Add(attrs...)
+
+	return record
+}
+
+type stackError struct {
+	error
+}
+
+func (stackError) Callers() []uintptr {
+	var pcs [1]uintptr
+	runtime.Callers(1, pcs[:])
+
+	return pcs[:]
+}
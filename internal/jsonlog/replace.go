@@ -0,0 +1,107 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+package jsonlog
+
+import "log/slog"
+
+// Fields are names of fields which attributes at the root of records are mapped to by ReplaceAttr.
+type Fields struct {
+	Time    string
+	Level   string
+	Message string
+	// Source maps the source of the record to the attribute of the field.
+	Source func(source *slog.Source) slog.Attr
+
+	// TraceKey and SpanKey are keys of trace attributes, which are mapped to Trace and Span.
+	TraceKey string
+	Trace    string
+	SpanKey  string
+	Span     string
+	// ID converts trace and span ids, and reports false if the id is invalid so the attribute is omitted.
+	// If it's nil, ids are kept as is.
+	ID func(id string) (string, bool)
+
+	// ErrorKey is the key of the error attribute, which is mapped to Error.
+	ErrorKey string
+	Error    string
+}
+
+// ReplaceAttr returns the function for slog.HandlerOptions which maps attributes to the given fields.
+func ReplaceAttr(fields Fields) func(groups []string, attr slog.Attr) slog.Attr {
+	return func(groups []string, attr slog.Attr) slog.Attr {
+		if len(groups) > 0 {
+			return attr
+		}
+
+		switch attr.Key {
+		case slog.TimeKey:
+			attr.Key = fields.Time
+
+			return attr
+
+		case slog.LevelKey:
+			// Resolve any slog.Leveler, including slog.Level, to the name of the standard level.
+			if leveler, ok := attr.Value.Resolve().Any().(slog.Leveler); ok {
+				return slog.String(fields.Level, levelName(leveler.Level()))
+			}
+			attr.Key = fields.Level
+
+			return attr
+
+		case slog.MessageKey:
+			attr.Key = fields.Message
+
+			return attr
+
+		case slog.SourceKey:
+			if source, ok := attr.Value.Resolve().Any().(*slog.Source); ok {
+				return fields.Source(source)
+			}
+
+			return attr
+
+		case fields.TraceKey:
+			return fields.id(fields.Trace, attr)
+
+		case fields.SpanKey:
+			return fields.id(fields.Span, attr)
+
+		case fields.ErrorKey:
+			attr.Key = fields.Error
+
+			return attr
+		}
+
+		return attr
+	}
+}
+
+func (f Fields) id(key string, attr slog.Attr) slog.Attr {
+	if f.ID == nil {
+		attr.Key = key
+
+		return attr
+	}
+
+	if id, ok := f.ID(attr.Value.Resolve().String()); ok {
+		return slog.String(key, id)
+	}
+
+	return slog.Attr{}
+}
+
+// levelName returns the lowercase name of the nearest standard level at or below the level,
+// e.g. "info" for slog.LevelInfo+2, since custom levels like "INFO+2" are not recognized by platforms.
+func levelName(level slog.Level) string {
+	switch {
+	case level >= slog.LevelError:
+		return "error"
+	case level >= slog.LevelWarn:
+		return "warn"
+	case level >= slog.LevelInfo:
+		return "info"
+	default:
+		return "debug"
+	}
+}
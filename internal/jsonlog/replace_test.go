@@ -0,0 +1,82 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+package jsonlog_test
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/nil-go/sloth/internal/assert"
+	"github.com/nil-go/sloth/internal/jsonlog"
+)
+
+func TestReplaceAttr_level(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		value       slog.Value
+		expected    string
+	}{
+		{
+			description: "standard level",
+			value:       slog.AnyValue(slog.LevelWarn),
+			expected:    "warn",
+		},
+		{
+			description: "custom level",
+			value:       slog.AnyValue(slog.LevelInfo + 2),
+			expected:    "info",
+		},
+		{
+			description: "below debug",
+			value:       slog.AnyValue(slog.LevelDebug - 4),
+			expected:    "debug",
+		},
+		{
+			description: "above error",
+			value:       slog.AnyValue(slog.LevelError + 4),
+			expected:    "error",
+		},
+		{
+			description: "leveler",
+			value:       slog.AnyValue(leveler(slog.LevelError)),
+			expected:    "error",
+		},
+	}
+
+	replaceAttr := jsonlog.ReplaceAttr(jsonlog.Fields{Level: "level"})
+	for _, testcase := range testcases {
+		t.Run(testcase.description, func(t *testing.T) {
+			t.Parallel()
+
+			attr := replaceAttr(nil, slog.Attr{Key: slog.LevelKey, Value: testcase.value})
+			assert.Equal(t, "level", attr.Key)
+			assert.Equal(t, testcase.expected, attr.Value.String())
+		})
+	}
+}
+
+func TestReplaceAttr_id(t *testing.T) {
+	t.Parallel()
+
+	replaceAttr := jsonlog.ReplaceAttr(jsonlog.Fields{
+		TraceKey: "trace_id",
+		Trace:    "trace.id",
+		ID: func(id string) (string, bool) {
+			return id, id != "invalid"
+		},
+	})
+
+	assert.Equal(t, slog.String("trace.id", "1"), replaceAttr(nil, slog.String("trace_id", "1")))
+	assert.Equal(t, slog.Attr{}, replaceAttr(nil, slog.String("trace_id", "invalid")))
+	// Attributes in groups are not replaced.
+	assert.Equal(t, slog.String("trace_id", "1"), replaceAttr([]string{"g"}, slog.String("trace_id", "1")))
+}
+
+type leveler slog.Level
+
+func (l leveler) Level() slog.Level {
+	return slog.Level(l)
+}
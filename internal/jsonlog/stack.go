@@ -0,0 +1,137 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+// Package jsonlog provides helpers shared by handlers which format records as JSON logs
+// for specific platforms, e.g. error lookup, stack trace rendering and group replay.
+package jsonlog
+
+import (
+	"errors"
+	"log/slog"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// RecordError returns the first error in the record, or the first error with the given key if it's not empty.
+func RecordError(record slog.Record, key string) error {
+	var err error
+	record.Attrs(func(attr slog.Attr) bool {
+		err = FindError(attr, key)
+
+		return err == nil
+	})
+
+	return err
+}
+
+// FindError finds the first error in the attribute, including attributes nested in groups.
+// It only matches attributes with the given key if it's not empty.
+func FindError(attr slog.Attr, key string) error {
+	value := attr.Value.Resolve()
+	if value.Kind() == slog.KindGroup {
+		for _, groupAttr := range value.Group() {
+			if err := FindError(groupAttr, key); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+	if key != "" && attr.Key != key {
+		return nil
+	}
+
+	if err, ok := value.Any().(error); ok {
+		return err
+	}
+
+	return nil
+}
+
+// ErrorCallers returns callers from method `Callers() []uintptr` of the error if it has,
+// which is the default for handlers without custom callers.
+func ErrorCallers(err error) []uintptr {
+	var callers interface{ Callers() []uintptr }
+	if errors.As(err, &callers) {
+		return callers.Callers()
+	}
+
+	return nil
+}
+
+// Callers returns callers on the calling goroutine's stack, starting from the first frame of the record.
+// If the first frame is not found, it returns all callers.
+func Callers(firstFrame runtime.Frame) []uintptr {
+	var pcs [32]uintptr
+	count := runtime.Callers(2, pcs[:]) //nolint:mnd // skip [runtime.Callers, this function]
+
+	// Skip frames before the first frame of the record.
+	callers := pcs[:count]
+	frames := runtime.CallersFrames(callers)
+	for {
+		frame, more := frames.Next()
+		if frame.Function == firstFrame.Function &&
+			frame.File == firstFrame.File &&
+			frame.Line == firstFrame.Line {
+			break
+		}
+		callers = callers[1:]
+		if !more {
+			break
+		}
+	}
+
+	if len(callers) > 0 {
+		return callers
+	}
+
+	// If the first frame is not found, all frames prints as stack trace.
+	return pcs[:count]
+}
+
+// Stack renders callers in the same format as the stack trace of a panic,
+// and writes it to the builder after the goroutine line with the given goroutine number.
+// Frames are dropped if the filter returns false, unless the filter drops all of them.
+func Stack(builder *strings.Builder, goroutine uint64, callers []uintptr, filter func(runtime.Frame) bool) {
+	frames := make([]runtime.Frame, 0, len(callers))
+	callerFrames := runtime.CallersFrames(callers)
+	for {
+		frame, more := callerFrames.Next()
+		if filter == nil || filter(frame) {
+			frames = append(frames, frame)
+		}
+		if !more {
+			break
+		}
+	}
+	if len(frames) == 0 && len(callers) > 0 {
+		// Keep all frames if the filter drops all of them, so the stack trace is still valid.
+		Stack(builder, goroutine, callers, nil)
+
+		return
+	}
+
+	builder.Grow(128 * len(frames)) //nolint:mnd // It assumes 128 bytes per frame.
+
+	// The goroutine number is meaningless in stack trace since every log may have different goroutine number,
+	// but it has to be a goroutine line to match the format of the stack trace of a panic.
+	builder.WriteString("goroutine ")
+	builder.WriteString(strconv.FormatUint(goroutine, 10))
+	builder.WriteString(" [running]:\n")
+
+	// Each frame has 2 lines in stack trace.
+	for _, frame := range frames {
+		// The first line is the function.
+		builder.WriteString(frame.Function)
+		builder.WriteString("()\n")
+		// The second line is the file:line.
+		builder.WriteString("\t")
+		builder.WriteString(frame.File)
+		builder.WriteString(":")
+		builder.WriteString(strconv.Itoa(frame.Line))
+		builder.WriteString(" +0x")
+		builder.WriteString(strconv.FormatUint(uint64(frame.PC-frame.Entry), 16))
+		builder.WriteString("\n")
+	}
+}
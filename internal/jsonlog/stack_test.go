@@ -0,0 +1,67 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+package jsonlog_test
+
+import (
+	"errors"
+	"log/slog"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nil-go/sloth/internal/assert"
+	"github.com/nil-go/sloth/internal/jsonlog"
+)
+
+func TestRecordError(t *testing.T) {
+	t.Parallel()
+
+	first, second := errors.New("first"), errors.New("second")
+	record := slog.NewRecord(time.Time{}, slog.LevelError, "error", 0)
+	record.AddAttrs(
+		slog.Group("g", slog.Any("cause", first)),
+		slog.Any("error", second),
+	)
+
+	assert.Equal(t, first, jsonlog.RecordError(record, ""))
+	assert.Equal(t, second, jsonlog.RecordError(record, "error"))
+	assert.Equal(t, nil, jsonlog.RecordError(record, "missing"))
+}
+
+func TestStack(t *testing.T) {
+	t.Parallel()
+
+	var pcs [1]uintptr
+	runtime.Callers(1, pcs[:])
+	frame, _ := runtime.CallersFrames(pcs[:]).Next()
+
+	testcases := []struct {
+		description string
+		filter      func(runtime.Frame) bool
+	}{
+		{
+			description: "without filter",
+		},
+		{
+			description: "filter drops all frames",
+			filter:      func(runtime.Frame) bool { return false },
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.description, func(t *testing.T) {
+			t.Parallel()
+
+			var builder strings.Builder
+			jsonlog.Stack(&builder, 2, pcs[:], testcase.filter)
+			stack := builder.String()
+			// Remove the offset of the PC since it depends on the compiler.
+			stack = stack[:strings.LastIndex(stack, " +0x")]
+			assert.Equal(t, "goroutine 2 [running]:\n"+
+				"github.com/nil-go/sloth/internal/jsonlog_test.TestStack()\n\t"+
+				frame.File+":37", stack)
+		})
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+/*
+Package metrics provides a handler for counting records by level.
+
+It counts records handled by the wrapped handler for each level, which is a cheap way
+to observe logging without depending on a metric SDK.
+
+	handler, counts := metrics.New(handler)
+	...
+	errors := counts()[slog.LevelError]
+*/
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+// Handler counts records by level.
+//
+// To create a new Handler, call [New].
+type Handler struct {
+	handler slog.Handler
+	counts  *sync.Map // map[slog.Level]*atomic.Int64
+}
+
+// New creates a new Handler, and a function which returns the snapshot of counts of records by level.
+func New(handler slog.Handler) (Handler, func() map[slog.Level]int64) {
+	if handler == nil {
+		panic("cannot create Handler with nil handler")
+	}
+
+	h := Handler{handler: handler, counts: &sync.Map{}}
+
+	return h, h.snapshot
+}
+
+func (h Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h Handler) Handle(ctx context.Context, record slog.Record) error {
+	count, ok := h.counts.Load(record.Level)
+	if !ok {
+		count, _ = h.counts.LoadOrStore(record.Level, &atomic.Int64{})
+	}
+	count.(*atomic.Int64).Add(1) //nolint:forcetypeassert // It's always *atomic.Int64.
+
+	return h.handler.Handle(ctx, record)
+}
+
+func (h Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h.handler = h.handler.WithAttrs(attrs)
+
+	return h
+}
+
+func (h Handler) WithGroup(name string) slog.Handler {
+	h.handler = h.handler.WithGroup(name)
+
+	return h
+}
+
+func (h Handler) snapshot() map[slog.Level]int64 {
+	counts := make(map[slog.Level]int64)
+	h.counts.Range(func(level, count any) bool {
+		counts[level.(slog.Level)] = count.(*atomic.Int64).Load() //nolint:forcetypeassert // It's always typed.
+
+		return true
+	})
+
+	return counts
+}
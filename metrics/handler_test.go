@@ -0,0 +1,56 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+package metrics_test
+
+import (
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"github.com/nil-go/sloth/internal/assert"
+	"github.com/nil-go/sloth/metrics"
+)
+
+func TestNew_panic(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		assert.Equal(t, "cannot create Handler with nil handler", recover().(string))
+	}()
+
+	metrics.New(nil)
+	t.Fail()
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	handler, counts := metrics.New(slog.NewTextHandler(io.Discard, nil))
+	assert.Equal(t, map[slog.Level]int64{}, counts())
+
+	logger := slog.New(handler.WithGroup("g").WithAttrs([]slog.Attr{slog.String("a", "A")}))
+	var waitGroup sync.WaitGroup
+	for range 10 {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+
+			for range 100 {
+				logger.Debug("debug")
+				logger.Info("info")
+				logger.Warn("warn")
+				logger.Error("error")
+				logger.Error("error")
+			}
+		}()
+	}
+	waitGroup.Wait()
+
+	assert.Equal(t, map[slog.Level]int64{
+		slog.LevelInfo:  1000,
+		slog.LevelWarn:  1000,
+		slog.LevelError: 2000,
+	}, counts())
+}
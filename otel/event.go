@@ -5,92 +5,261 @@ package otel
 
 import (
 	"context"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"log/slog"
+	"reflect"
 	"runtime"
 	"slices"
 	"strconv"
 	"time"
+	"unicode/utf8"
 
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
 	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
 	"go.opentelemetry.io/otel/trace"
 )
 
 type eventHandler struct {
-	prefix string
-	attrs  []attribute.KeyValue
+	prefix    string
+	separator string
+	groups    []string
+	attrs     []attribute.KeyValue
+
+	replaceAttr func(groups []string, attr slog.Attr) slog.Attr
+
+	timeAsUnixNano bool
+	maxValueLength int
+	levelAttribute bool
+	constAttrs     []attribute.KeyValue
+
+	separateExceptions bool
+	spanAttributeKeys  []string
+	callerSkip         int
+
+	recordUnsampled bool
+
+	counter metric.Int64Counter
 }
 
 func (e eventHandler) Enabled(ctx context.Context) bool {
 	span := trace.SpanFromContext(ctx)
 
-	return span.IsRecording() && span.SpanContext().IsSampled()
+	return span.IsRecording() && (e.recordUnsampled || span.SpanContext().IsSampled())
 }
 
 func (e eventHandler) Handle(ctx context.Context, record slog.Record) {
 	attrs := slices.Clone(e.attrs)
 	attrs = slices.Grow(attrs, record.NumAttrs())
-	errs := make(map[string]error)
+	var errs []keyedError
+	var links []trace.Link
 	record.Attrs(
 		func(attr slog.Attr) bool {
+			attr, keep := e.replace(e.groups, attr)
+			if !keep {
+				return true
+			}
+
 			if err, ok := attr.Value.Resolve().Any().(error); ok {
-				errs[attr.Key] = err
+				errs = append(errs, keyedError{key: attr.Key, err: err})
+			} else if link, ok := spanLink(attr); ok {
+				links = append(links, link)
 			} else {
-				attrs = append(attrs, convertAttr(attr, e.prefix)...)
+				attrs = append(attrs, e.convertAttr(attr, e.prefix)...)
 			}
 
 			return true
 		},
 	)
 
-	firstFrame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
+	span := trace.SpanFromContext(ctx)
+	if len(e.spanAttributeKeys) > 0 {
+		var spanAttrs []attribute.KeyValue
+		for _, attr := range attrs {
+			if slices.Contains(e.spanAttributeKeys, string(attr.Key)) {
+				spanAttrs = append(spanAttrs, attr)
+			}
+		}
+		if len(spanAttrs) > 0 {
+			span.SetAttributes(spanAttrs...)
+		}
+	}
+
+	firstFrame := e.codeFrame(record.PC)
 	attrs = append(attrs,
 		semconv.CodeFilepath(firstFrame.File),
 		semconv.CodeLineNumber(firstFrame.Line),
 		semconv.CodeFunction(firstFrame.Function),
 	)
+	if e.levelAttribute {
+		attrs = append(attrs, attribute.String("log.severity", record.Level.String()))
+	}
+	attrs = append(attrs, e.constAttrs...)
 
-	span := trace.SpanFromContext(ctx)
+	if e.counter != nil {
+		// The context carries the span, so the metric SDK could sample it as the exemplar.
+		// Only the level is recorded since messages would create unbounded time series.
+		e.counter.Add(ctx, 1, metric.WithAttributes(attribute.String("log.severity", record.Level.String())))
+	}
+
+	for _, link := range links {
+		span.AddLink(link)
+	}
 	switch {
+	case record.Level >= slog.LevelError && e.separateExceptions && len(errs) > 0:
+		for _, err := range errs {
+			span.RecordError(
+				fmt.Errorf("%s: %w", record.Message, err.err),
+				trace.WithTimestamp(record.Time),
+				trace.WithAttributes(append(slices.Clip(attrs), semconv.ExceptionType(typeName(err.err)))...),
+			)
+		}
+		span.SetStatus(codes.Error, record.Message)
 	case record.Level >= slog.LevelError:
 		var err error
 		for _, e := range errs {
-			err = errors.Join(err, e)
+			err = errors.Join(err, e.err)
 		}
 		if err == nil {
 			err = errors.New(record.Message) //nolint:goerr113
 		} else {
 			err = fmt.Errorf("%s: %w", record.Message, err)
+			// The type of the wrapped error is useless, so use the type of the first underlying error instead.
+			attrs = append(attrs, semconv.ExceptionType(typeName(errs[0].err)))
 		}
 		span.RecordError(err, trace.WithTimestamp(record.Time), trace.WithAttributes(attrs...))
 		span.SetStatus(codes.Error, record.Message)
 	default:
-		for k, v := range errs {
-			attrs = append(attrs, attribute.String(e.prefix+k, v.Error()))
+		for _, err := range errs {
+			attrs = append(attrs, attribute.String(e.prefix+err.key, e.truncate(err.err.Error())))
 		}
 		span.AddEvent(record.Message, trace.WithTimestamp(record.Time), trace.WithAttributes(attrs...))
 	}
 }
 
+// codeFrame returns the frame for code attributes, which skips callerSkip frames from the frame of pc
+// by re-walking the stack of the calling goroutine. It returns the frame of pc if the frame is not on the stack,
+// e.g. the record is handled asynchronously.
+func (e eventHandler) codeFrame(pc uintptr) runtime.Frame {
+	firstFrame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	if e.callerSkip <= 0 {
+		return firstFrame
+	}
+
+	var pcs [64]uintptr
+	count := runtime.Callers(2, pcs[:]) //nolint:mnd // skip [runtime.Callers, this function]
+	frames := runtime.CallersFrames(pcs[:count])
+	skip := -1 // Negative means the frame of pc has not been found yet.
+	for {
+		frame, more := frames.Next()
+		if skip < 0 {
+			// Match the function only since the wrapper might capture pc at a different line from the call.
+			if frame.Function == firstFrame.Function {
+				skip = e.callerSkip
+			}
+		} else if skip--; skip == 0 {
+			return frame
+		}
+		if !more {
+			return firstFrame
+		}
+	}
+}
+
+type keyedError struct {
+	key string
+	err error
+}
+
+// typeName returns the name of the error type in the same way as RecordError of the Open Telemetry SDK.
+func typeName(err error) string {
+	typ := reflect.TypeOf(err)
+	if typ.PkgPath() == "" && typ.Name() == "" {
+		// Likely a builtin type, e.g. a pointer.
+		return typ.String()
+	}
+
+	return typ.PkgPath() + "." + typ.Name()
+}
+
+// spanLink converts the group attribute with key LinkKey to the link of span.
+func spanLink(attr slog.Attr) (trace.Link, bool) {
+	if attr.Key != LinkKey {
+		return trace.Link{}, false
+	}
+	value := attr.Value.Resolve()
+	if value.Kind() != slog.KindGroup {
+		return trace.Link{}, false
+	}
+
+	var config trace.SpanContextConfig
+	for _, groupAttr := range value.Group() {
+		switch groupAttr.Key {
+		case TraceKey:
+			config.TraceID, _ = trace.TraceIDFromHex(groupAttr.Value.Resolve().String())
+		case SpanKey:
+			config.SpanID, _ = trace.SpanIDFromHex(groupAttr.Value.Resolve().String())
+		case TraceFlagsKey:
+			if flags, _ := hex.DecodeString(groupAttr.Value.Resolve().String()); len(flags) > 0 {
+				config.TraceFlags = trace.TraceFlags(flags[0])
+			}
+		}
+	}
+	spanContext := trace.NewSpanContext(config)
+
+	return trace.Link{SpanContext: spanContext}, spanContext.IsValid()
+}
+
 func (e eventHandler) WithAttrs(attrs []slog.Attr) eventHandler {
 	e.attrs = slices.Clone(e.attrs)
 	for _, attr := range attrs {
-		e.attrs = append(e.attrs, convertAttr(attr, e.prefix)...)
+		if attr, ok := e.replace(e.groups, attr); ok {
+			e.attrs = append(e.attrs, e.convertAttr(attr, e.prefix)...)
+		}
 	}
 
 	return e
 }
 
 func (e eventHandler) WithGroup(name string) eventHandler {
-	e.prefix = e.prefix + name + "."
+	e.prefix = e.prefix + name + e.separator
+	e.groups = append(slices.Clip(e.groups), name)
 
 	return e
 }
 
-func convertAttr(attr slog.Attr, prefix string) []attribute.KeyValue { //nolint:cyclop,funlen
+// replace applies replaceAttr to the attribute like slog.HandlerOptions.ReplaceAttr,
+// which recurses into group attributes, and reports false if the attribute is dropped.
+func (e eventHandler) replace(groups []string, attr slog.Attr) (slog.Attr, bool) {
+	if e.replaceAttr == nil {
+		return attr, true
+	}
+
+	attr.Value = attr.Value.Resolve()
+	if attr.Value.Kind() != slog.KindGroup {
+		attr = e.replaceAttr(groups, attr)
+
+		return attr, !attr.Equal(slog.Attr{})
+	}
+
+	if attr.Key != "" {
+		groups = append(slices.Clip(groups), attr.Key)
+	}
+	groupAttrs := make([]slog.Attr, 0, len(attr.Value.Group()))
+	for _, groupAttr := range attr.Value.Group() {
+		if groupAttr, ok := e.replace(groups, groupAttr); ok {
+			groupAttrs = append(groupAttrs, groupAttr)
+		}
+	}
+	attr.Value = slog.GroupValue(groupAttrs...)
+
+	return attr, len(groupAttrs) > 0
+}
+
+func (e eventHandler) convertAttr(attr slog.Attr, prefix string) []attribute.KeyValue { //nolint:cyclop,funlen
 	key := prefix + attr.Key
 	value := attr.Value
 
@@ -109,33 +278,59 @@ func convertAttr(attr slog.Attr, prefix string) []attribute.KeyValue { //nolint:
 		case []bool:
 			attrs = append(attrs, attribute.BoolSlice(key, val))
 		case fmt.Stringer:
-			attrs = append(attrs, attribute.Stringer(key, val))
+			attrs = append(attrs, attribute.String(key, e.truncate(val.String())))
 		default:
-			attrs = append(attrs, attribute.String(key, fmt.Sprintf("%v", val)))
+			attrs = append(attrs, attribute.String(key, e.truncate(fmt.Sprintf("%v", val))))
 		}
 	case slog.KindBool:
 		attrs = append(attrs, attribute.Bool(key, value.Bool()))
 	case slog.KindDuration:
-		attrs = append(attrs, attribute.String(key, value.Duration().String()))
+		if e.timeAsUnixNano {
+			attrs = append(attrs, attribute.Int64(key, value.Duration().Nanoseconds()))
+		} else {
+			attrs = append(attrs, attribute.String(key, value.Duration().String()))
+		}
 	case slog.KindFloat64:
 		attrs = append(attrs, attribute.Float64(key, value.Float64()))
 	case slog.KindInt64:
 		attrs = append(attrs, attribute.Int64(key, value.Int64()))
 	case slog.KindString:
-		attrs = append(attrs, attribute.String(key, value.String()))
+		attrs = append(attrs, attribute.String(key, e.truncate(value.String())))
 	case slog.KindTime:
-		attrs = append(attrs, attribute.String(key, value.Time().Format(time.RFC3339Nano)))
+		if e.timeAsUnixNano {
+			attrs = append(attrs, attribute.Int64(key, value.Time().UnixNano()))
+		} else {
+			attrs = append(attrs, attribute.String(key, value.Time().Format(time.RFC3339Nano)))
+		}
 	case slog.KindUint64:
 		attrs = append(attrs, attribute.String(key, strconv.FormatUint(value.Uint64(), 10)))
 	case slog.KindGroup:
 		attrs = slices.Grow(attrs, len(value.Group()))
 		for _, groupAttr := range value.Group() {
-			attrs = append(attrs, convertAttr(groupAttr, key+".")...)
+			attrs = append(attrs, e.convertAttr(groupAttr, key+e.separator)...)
 		}
 	case slog.KindLogValuer:
 		attr.Value = attr.Value.Resolve()
-		attrs = append(attrs, convertAttr(attr, prefix)...)
+		attrs = append(attrs, e.convertAttr(attr, prefix)...)
 	}
 
 	return attrs
 }
+
+// truncate clamps the value to maxValueLength characters, including the trailing ellipsis.
+func (e eventHandler) truncate(value string) string {
+	if e.maxValueLength <= 0 || len(value) <= e.maxValueLength ||
+		utf8.RuneCountInString(value) <= e.maxValueLength {
+		return value
+	}
+
+	var count int
+	for i := range value {
+		if count == e.maxValueLength-1 {
+			return value[:i] + "…"
+		}
+		count++
+	}
+
+	return value
+}
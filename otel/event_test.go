@@ -0,0 +1,87 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+package otel_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+
+	"github.com/nil-go/sloth/otel"
+	"github.com/nil-go/sloth/otel/internal/assert"
+)
+
+// The SDK appends the type of the recorded error after attributes of RecordError,
+// so the exception.type provided by the handler is the first one.
+func TestHandler_sdkExceptionType(t *testing.T) {
+	t.Parallel()
+
+	events := sdkEvents(t, otel.NewEventOnly(), func(ctx context.Context, logger *slog.Logger) {
+		logger.ErrorContext(ctx, "failed", "first", timeoutError{}, "second", errors.New("an error"))
+	})
+
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, semconv.ExceptionEventName, events[0].Name)
+	assert.Equal(t, attribute.StringValue("github.com/nil-go/sloth/otel_test.timeoutError"),
+		values(events[0].Attributes, semconv.ExceptionTypeKey)[0])
+	assert.Equal(t, []attribute.Value{attribute.StringValue("failed: timeout\nan error")},
+		values(events[0].Attributes, semconv.ExceptionMessageKey))
+}
+
+func TestHandler_sdkSeparateExceptions(t *testing.T) {
+	t.Parallel()
+
+	events := sdkEvents(t, otel.NewEventOnly(otel.WithSeparateExceptions(true)),
+		func(ctx context.Context, logger *slog.Logger) {
+			logger.ErrorContext(ctx, "failed", "first", timeoutError{}, "second", errors.New("an error"))
+		},
+	)
+
+	assert.Equal(t, 2, len(events))
+	assert.Equal(t, attribute.StringValue("github.com/nil-go/sloth/otel_test.timeoutError"),
+		values(events[0].Attributes, semconv.ExceptionTypeKey)[0])
+	assert.Equal(t, []attribute.Value{attribute.StringValue("failed: timeout")},
+		values(events[0].Attributes, semconv.ExceptionMessageKey))
+	assert.Equal(t, attribute.StringValue("*errors.errorString"),
+		values(events[1].Attributes, semconv.ExceptionTypeKey)[0])
+	assert.Equal(t, []attribute.Value{attribute.StringValue("failed: an error")},
+		values(events[1].Attributes, semconv.ExceptionMessageKey))
+}
+
+func sdkEvents(
+	t *testing.T,
+	handler slog.Handler,
+	log func(ctx context.Context, logger *slog.Logger),
+) []sdktrace.Event {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	ctx, span := provider.Tracer("test").Start(context.Background(), "span")
+	log(ctx, slog.New(handler))
+	span.End()
+
+	spans := recorder.Ended()
+	assert.Equal(t, 1, len(spans))
+
+	return spans[0].Events()
+}
+
+// values returns values of all attributes with the given key in order.
+func values(attrs []attribute.KeyValue, key attribute.Key) []attribute.Value {
+	var matched []attribute.Value
+	for _, attr := range attrs {
+		if attr.Key == key {
+			matched = append(matched, attr.Value)
+		}
+	}
+
+	return matched
+}
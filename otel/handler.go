@@ -8,6 +8,9 @@ It adds [W3C Trace Context] attributes to log records if there is a span in the
 so the logs could be correlated with the spans in the distributed tracing system.
 
 It also records log records as trace span's events if it's enabled.
+
+The samplers in this package, e.g. TraceSampler, could be composed with AnySampler and AllSampler
+as the sampler of the sampling handler, so logs are sampled consistently with traces.
 */
 package otel
 
@@ -43,16 +46,36 @@ const (
 	//
 	// [tracing flags]: https://www.w3.org/TR/trace-context/#trace-flags
 	TraceFlagsKey = "trace_flags"
+
+	// LinkKey is the key of the group attribute which carries TraceKey, SpanKey and optional TraceFlagsKey
+	// of a causally-related span, e.g. an async job. It is recorded as a [link] of the span
+	// instead of an event attribute if recording events is enabled.
+	//
+	//	logger.Info("job enqueued", slog.Group(otel.LinkKey, otel.TraceKey, traceID, otel.SpanKey, spanID))
+	//
+	// [link]: https://opentelemetry.io/docs/concepts/signals/traces/#span-links
+	LinkKey = "link"
+
+	// SpanNameKey is the key used by the name of the span in the context if WithSpanName is enabled.
+	SpanNameKey = "span_name"
 )
 
 // Handler correlates log records with Open Telemetry spans.
 //
-// To create a new Handler, call [New].
+// To create a new Handler, call [New] or [NewEventOnly].
 type Handler struct {
-	handler slog.Handler
-
-	recordEvent bool
-	passThrough bool
+	handler      slog.Handler
+	spanContext  func(context.Context) trace.SpanContext
+	traceContext func(context.Context) (traceID [16]byte, spanID [8]byte, traceFlags byte)
+	level        slog.Leveler
+	eventLevel   slog.Leveler
+
+	onlySampledTrace  bool
+	noTraceAttributes bool
+	spanName          bool
+	recordEvent       bool
+	passThrough       bool
+	eventOnly         bool
 
 	groups       []group
 	eventHandler eventHandler
@@ -69,29 +92,92 @@ func New(handler slog.Handler, opts ...Option) Handler {
 		panic("cannot create Handler with nil handler")
 	}
 
-	option := &options{handler: handler, eventHandler: eventHandler{}}
+	return newHandler(&options{handler: handler}, opts)
+}
+
+// NewEventOnly creates a new Handler with the given Option(s), which only records log records
+// as events of the span in the context without a wrapped handler, e.g. no text logs are needed.
+// Log records are recorded as events like WithRecordEvent(false), and discarded if the span is not recording.
+// Options for trace attributes, e.g. WithOnlySampledTrace, have no effect.
+func NewEventOnly(opts ...Option) Handler {
+	return newHandler(&options{recordEvent: true, eventOnly: true}, opts)
+}
+
+func newHandler(option *options, opts []Option) Handler {
 	for _, opt := range opts {
 		opt(option)
 	}
+	if option.spanContext == nil {
+		option.spanContext = trace.SpanContextFromContext
+	}
+	if option.eventHandler.separator == "" {
+		option.eventHandler.separator = "."
+	}
+	if option.eventOnly {
+		option.recordEvent = true
+		option.passThrough = false
+	}
 
 	return Handler(*option)
 }
 
 func (h Handler) Enabled(ctx context.Context, level slog.Level) bool {
-	return h.handler.Enabled(ctx, level)
+	if h.level != nil && level < h.level.Level() {
+		return false
+	}
+	if h.eventOnly {
+		return h.eventHandler.Enabled(ctx)
+	}
+	if h.handler.Enabled(ctx, level) {
+		return true
+	}
+
+	return h.recordEvent && h.eventLevel != nil && level >= h.eventLevel.Level() && h.eventHandler.Enabled(ctx)
 }
 
 func (h Handler) Handle(ctx context.Context, record slog.Record) error {
+	if h.level != nil && record.Level < h.level.Level() {
+		return nil
+	}
+	if h.eventOnly {
+		if h.eventHandler.Enabled(ctx) {
+			h.eventHandler.Handle(ctx, record)
+		}
+
+		return nil
+	}
+
 	handler := h.handler
-	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
-		tid := spanContext.TraceID()
-		sid := spanContext.SpanID()
-		flags := spanContext.TraceFlags()
-		handler = handler.WithAttrs([]slog.Attr{
-			slog.String(TraceKey, hex.EncodeToString(tid[:])),
-			slog.String(SpanKey, hex.EncodeToString(sid[:])),
-			slog.String(TraceFlagsKey, hex.EncodeToString([]byte{byte(flags)})),
+	spanContext := h.spanContext(ctx)
+	if !spanContext.IsValid() && h.traceContext != nil {
+		traceID, spanID, traceFlags := h.traceContext(ctx)
+		spanContext = trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    traceID,
+			SpanID:     spanID,
+			TraceFlags: trace.TraceFlags(traceFlags),
 		})
+	}
+	if spanContext.IsValid() {
+		var attrs []slog.Attr
+		if !h.noTraceAttributes && (!h.onlySampledTrace || spanContext.IsSampled()) {
+			tid := spanContext.TraceID()
+			sid := spanContext.SpanID()
+			flags := spanContext.TraceFlags()
+			attrs = append(attrs,
+				slog.String(TraceKey, hex.EncodeToString(tid[:])),
+				slog.String(SpanKey, hex.EncodeToString(sid[:])),
+				slog.String(TraceFlagsKey, hex.EncodeToString([]byte{byte(flags)})),
+			)
+		}
+		if h.spanName {
+			// trace.Span does not expose the name, but spans of the SDK do, e.g. ReadOnlySpan.
+			if span, ok := trace.SpanFromContext(ctx).(interface{ Name() string }); ok && span.Name() != "" {
+				attrs = append(attrs, slog.String(SpanNameKey, span.Name()))
+			}
+		}
+		if len(attrs) > 0 {
+			handler = handler.WithAttrs(attrs)
+		}
 
 		if h.recordEvent && h.eventHandler.Enabled(ctx) {
 			h.eventHandler.Handle(ctx, record)
@@ -101,6 +187,11 @@ func (h Handler) Handle(ctx context.Context, record slog.Record) error {
 		}
 	}
 
+	// The record might only be enabled for recording the event.
+	if h.eventLevel != nil && !h.handler.Enabled(ctx, record.Level) {
+		return nil
+	}
+
 	for _, group := range h.groups {
 		handler = handler.WithGroup(group.name).WithAttrs(group.attrs)
 	}
@@ -110,6 +201,9 @@ func (h Handler) Handle(ctx context.Context, record slog.Record) error {
 
 func (h Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
 	h.eventHandler = h.eventHandler.WithAttrs(attrs)
+	if h.eventOnly {
+		return h
+	}
 
 	if len(h.groups) == 0 {
 		h.handler = h.handler.WithAttrs(attrs)
@@ -124,7 +218,15 @@ func (h Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
 }
 
 func (h Handler) WithGroup(name string) slog.Handler {
+	// Empty group name is a no-op as required by slog.Handler.
+	if name == "" {
+		return h
+	}
+
 	h.eventHandler = h.eventHandler.WithGroup(name)
+	if h.eventOnly {
+		return h
+	}
 
 	h.groups = slices.Clone(h.groups)
 	h.groups = append(h.groups, group{name: name})
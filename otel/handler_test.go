@@ -11,11 +11,13 @@ import (
 	"log/slog"
 	"os"
 	"runtime"
+	"strings"
 	"testing"
 	"time"
 
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
 	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
 	"go.opentelemetry.io/otel/trace"
 
@@ -124,6 +126,36 @@ level=INFO msg=msg3 g.h.error="an error"
 			expectedLog: `level=INFO msg=msg1 a=A trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7 trace_flags=00
 level=INFO msg=msg2 trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7 trace_flags=00 g.b=B
 level=INFO msg=msg3 trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7 trace_flags=00 g.h.error="an error"
+`,
+		},
+		{
+			description: "only sampled trace (sampled)",
+			spanContext: trace.NewSpanContext(trace.SpanContextConfig{
+				TraceID:    [16]byte{75, 249, 47, 53, 119, 179, 77, 166, 163, 206, 146, 157, 14, 14, 71, 54},
+				SpanID:     [8]byte{0, 240, 103, 170, 11, 169, 2, 183},
+				TraceFlags: trace.TraceFlags(1),
+			}),
+			opts: []otel.Option{
+				otel.WithOnlySampledTrace(true),
+			},
+			expectedLog: `level=INFO msg=msg1 a=A trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7 trace_flags=01
+level=INFO msg=msg2 trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7 trace_flags=01 g.b=B
+level=INFO msg=msg3 trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7 trace_flags=01 g.h.error="an error"
+`,
+		},
+		{
+			description: "only sampled trace (not sampled)",
+			spanContext: trace.NewSpanContext(trace.SpanContextConfig{
+				TraceID:    [16]byte{75, 249, 47, 53, 119, 179, 77, 166, 163, 206, 146, 157, 14, 14, 71, 54},
+				SpanID:     [8]byte{0, 240, 103, 170, 11, 169, 2, 183},
+				TraceFlags: trace.TraceFlags(0),
+			}),
+			opts: []otel.Option{
+				otel.WithOnlySampledTrace(true),
+			},
+			expectedLog: `level=INFO msg=msg1 a=A
+level=INFO msg=msg2 g.b=B
+level=INFO msg=msg3 g.h.error="an error"
 `,
 		},
 		{
@@ -179,15 +211,73 @@ level=INFO msg=msg3 g.h.error="an error"
 				events: map[string][]trace.EventOption{
 					"msg1": {
 						trace.WithTimestamp(time.Unix(100, 1000)),
-						trace.WithAttributes(attribute.String("a", "A"), filePath, semconv.CodeLineNumber(71), function),
+						trace.WithAttributes(attribute.String("a", "A"), filePath, semconv.CodeLineNumber(73), function),
+					},
+					"msg2": {
+						trace.WithTimestamp(time.Unix(100, 1000)),
+						trace.WithAttributes(attribute.String("g.b", "B"), filePath, semconv.CodeLineNumber(76), function),
+					},
+					"msg3": {
+						trace.WithTimestamp(time.Unix(100, 1000)),
+						trace.WithAttributes(filePath, semconv.CodeLineNumber(78), function, attribute.String("g.h.error", "an error")),
+					},
+				},
+			},
+		},
+		{
+			description: "with record unsampled",
+			spanContext: trace.NewSpanContext(trace.SpanContextConfig{
+				TraceID:    [16]byte{75, 249, 47, 53, 119, 179, 77, 166, 163, 206, 146, 157, 14, 14, 71, 54},
+				SpanID:     [8]byte{0, 240, 103, 170, 11, 169, 2, 183},
+				TraceFlags: trace.TraceFlags(0),
+			}),
+			recording: true,
+			opts: []otel.Option{
+				otel.WithRecordEvent(false),
+				otel.WithRecordUnsampled(true),
+			},
+			expectedSpan: spanStub{
+				events: map[string][]trace.EventOption{
+					"msg1": {
+						trace.WithTimestamp(time.Unix(100, 1000)),
+						trace.WithAttributes(attribute.String("a", "A"), filePath, semconv.CodeLineNumber(73), function),
+					},
+					"msg2": {
+						trace.WithTimestamp(time.Unix(100, 1000)),
+						trace.WithAttributes(attribute.String("g.b", "B"), filePath, semconv.CodeLineNumber(76), function),
+					},
+					"msg3": {
+						trace.WithTimestamp(time.Unix(100, 1000)),
+						trace.WithAttributes(filePath, semconv.CodeLineNumber(78), function, attribute.String("g.h.error", "an error")),
+					},
+				},
+			},
+		},
+		{
+			description: "with attribute separator",
+			spanContext: trace.NewSpanContext(trace.SpanContextConfig{
+				TraceID:    [16]byte{75, 249, 47, 53, 119, 179, 77, 166, 163, 206, 146, 157, 14, 14, 71, 54},
+				SpanID:     [8]byte{0, 240, 103, 170, 11, 169, 2, 183},
+				TraceFlags: trace.TraceFlags(1),
+			}),
+			recording: true,
+			opts: []otel.Option{
+				otel.WithRecordEvent(false),
+				otel.WithAttributeSeparator("_"),
+			},
+			expectedSpan: spanStub{
+				events: map[string][]trace.EventOption{
+					"msg1": {
+						trace.WithTimestamp(time.Unix(100, 1000)),
+						trace.WithAttributes(attribute.String("a", "A"), filePath, semconv.CodeLineNumber(73), function),
 					},
 					"msg2": {
 						trace.WithTimestamp(time.Unix(100, 1000)),
-						trace.WithAttributes(attribute.String("g.b", "B"), filePath, semconv.CodeLineNumber(74), function),
+						trace.WithAttributes(attribute.String("g_b", "B"), filePath, semconv.CodeLineNumber(76), function),
 					},
 					"msg3": {
 						trace.WithTimestamp(time.Unix(100, 1000)),
-						trace.WithAttributes(filePath, semconv.CodeLineNumber(76), function, attribute.String("g.h.error", "an error")),
+						trace.WithAttributes(filePath, semconv.CodeLineNumber(78), function, attribute.String("g_h_error", "an error")),
 					},
 				},
 			},
@@ -208,15 +298,52 @@ level=INFO msg=msg3 g.h.error="an error"
 				errors: map[error][]trace.EventOption{
 					errors.New("msg1"): {
 						trace.WithTimestamp(time.Unix(100, 1000)),
-						trace.WithAttributes(attribute.String("a", "A"), filePath, semconv.CodeLineNumber(71), function),
+						trace.WithAttributes(attribute.String("a", "A"), filePath, semconv.CodeLineNumber(73), function),
+					},
+					errors.New("msg2"): {
+						trace.WithTimestamp(time.Unix(100, 1000)),
+						trace.WithAttributes(attribute.String("g.b", "B"), filePath, semconv.CodeLineNumber(76), function),
+					},
+					fmt.Errorf("msg3: %w", errors.New("an error")): {
+						trace.WithTimestamp(time.Unix(100, 1000)),
+						trace.WithAttributes(filePath, semconv.CodeLineNumber(78), function,
+							semconv.ExceptionType("*errors.errorString")),
+					},
+				},
+				status:  codes.Error,
+				message: "msg3",
+			},
+		},
+		{
+			description: "with record event (error, pass through)",
+			level:       slog.LevelError,
+			spanContext: trace.NewSpanContext(trace.SpanContextConfig{
+				TraceID:    [16]byte{75, 249, 47, 53, 119, 179, 77, 166, 163, 206, 146, 157, 14, 14, 71, 54},
+				SpanID:     [8]byte{0, 240, 103, 170, 11, 169, 2, 183},
+				TraceFlags: trace.TraceFlags(1),
+			}),
+			recording: true,
+			opts: []otel.Option{
+				otel.WithRecordEvent(true),
+			},
+			expectedLog: `level=ERROR msg=msg1 a=A trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7 trace_flags=01
+level=ERROR msg=msg2 trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7 trace_flags=01 g.b=B
+level=ERROR msg=msg3 trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7 trace_flags=01 g.h.error="an error"
+`,
+			expectedSpan: spanStub{
+				errors: map[error][]trace.EventOption{
+					errors.New("msg1"): {
+						trace.WithTimestamp(time.Unix(100, 1000)),
+						trace.WithAttributes(attribute.String("a", "A"), filePath, semconv.CodeLineNumber(73), function),
 					},
 					errors.New("msg2"): {
 						trace.WithTimestamp(time.Unix(100, 1000)),
-						trace.WithAttributes(attribute.String("g.b", "B"), filePath, semconv.CodeLineNumber(74), function),
+						trace.WithAttributes(attribute.String("g.b", "B"), filePath, semconv.CodeLineNumber(76), function),
 					},
 					fmt.Errorf("msg3: %w", errors.New("an error")): {
 						trace.WithTimestamp(time.Unix(100, 1000)),
-						trace.WithAttributes(filePath, semconv.CodeLineNumber(76), function),
+						trace.WithAttributes(filePath, semconv.CodeLineNumber(78), function,
+							semconv.ExceptionType("*errors.errorString")),
 					},
 				},
 				status:  codes.Error,
@@ -242,19 +369,48 @@ level=INFO msg=msg3 trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0b
 				events: map[string][]trace.EventOption{
 					"msg1": {
 						trace.WithTimestamp(time.Unix(100, 1000)),
-						trace.WithAttributes(attribute.String("a", "A"), filePath, semconv.CodeLineNumber(71), function),
+						trace.WithAttributes(attribute.String("a", "A"), filePath, semconv.CodeLineNumber(73), function),
 					},
 					"msg2": {
 						trace.WithTimestamp(time.Unix(100, 1000)),
-						trace.WithAttributes(attribute.String("g.b", "B"), filePath, semconv.CodeLineNumber(74), function),
+						trace.WithAttributes(attribute.String("g.b", "B"), filePath, semconv.CodeLineNumber(76), function),
 					},
 					"msg3": {
 						trace.WithTimestamp(time.Unix(100, 1000)),
-						trace.WithAttributes(filePath, semconv.CodeLineNumber(76), function, attribute.String("g.h.error", "an error")),
+						trace.WithAttributes(filePath, semconv.CodeLineNumber(78), function, attribute.String("g.h.error", "an error")),
 					},
 				},
 			},
 		},
+		{
+			description: "with level (below)",
+			spanContext: trace.NewSpanContext(trace.SpanContextConfig{
+				TraceID:    [16]byte{75, 249, 47, 53, 119, 179, 77, 166, 163, 206, 146, 157, 14, 14, 71, 54},
+				SpanID:     [8]byte{0, 240, 103, 170, 11, 169, 2, 183},
+				TraceFlags: trace.TraceFlags(1),
+			}),
+			recording: true,
+			opts: []otel.Option{
+				otel.WithRecordEvent(true),
+				otel.WithLevel(slog.LevelWarn),
+			},
+		},
+		{
+			description: "with level (above)",
+			level:       slog.LevelWarn,
+			spanContext: trace.NewSpanContext(trace.SpanContextConfig{
+				TraceID:    [16]byte{75, 249, 47, 53, 119, 179, 77, 166, 163, 206, 146, 157, 14, 14, 71, 54},
+				SpanID:     [8]byte{0, 240, 103, 170, 11, 169, 2, 183},
+				TraceFlags: trace.TraceFlags(1),
+			}),
+			opts: []otel.Option{
+				otel.WithLevel(slog.LevelWarn),
+			},
+			expectedLog: `level=WARN msg=msg1 a=A trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7 trace_flags=01
+level=WARN msg=msg2 trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7 trace_flags=01 g.b=B
+level=WARN msg=msg3 trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7 trace_flags=01 g.h.error="an error"
+`,
+		},
 		{
 			description: "with record event (invalid span context)",
 			spanContext: trace.NewSpanContext(trace.SpanContextConfig{
@@ -269,7 +425,54 @@ level=INFO msg=msg2 g.b=B
 level=INFO msg=msg3 g.h.error="an error"
 `,
 		},
+		{
+			description: "without trace attributes",
+			spanContext: trace.NewSpanContext(trace.SpanContextConfig{
+				TraceID:    [16]byte{75, 249, 47, 53, 119, 179, 77, 166, 163, 206, 146, 157, 14, 14, 71, 54},
+				SpanID:     [8]byte{0, 240, 103, 170, 11, 169, 2, 183},
+				TraceFlags: trace.TraceFlags(1),
+			}),
+			recording: true,
+			opts: []otel.Option{
+				otel.WithRecordEvent(true),
+				otel.WithTraceAttributes(false),
+			},
+			expectedLog: `level=INFO msg=msg1 a=A
+level=INFO msg=msg2 g.b=B
+level=INFO msg=msg3 g.h.error="an error"
+`,
+			expectedSpan: spanStub{
+				events: map[string][]trace.EventOption{
+					"msg1": {
+						trace.WithTimestamp(time.Unix(100, 1000)),
+						trace.WithAttributes(attribute.String("a", "A"), filePath, semconv.CodeLineNumber(73), function),
+					},
+					"msg2": {
+						trace.WithTimestamp(time.Unix(100, 1000)),
+						trace.WithAttributes(attribute.String("g.b", "B"), filePath, semconv.CodeLineNumber(76), function),
+					},
+					"msg3": {
+						trace.WithTimestamp(time.Unix(100, 1000)),
+						trace.WithAttributes(filePath, semconv.CodeLineNumber(78), function, attribute.String("g.h.error", "an error")),
+					},
+				},
+			},
+		},
+	}
+}
+
+type counterStub struct {
+	metric.Int64Counter
+
+	values map[attribute.Distinct]int64
+}
+
+func (c *counterStub) Add(_ context.Context, incr int64, options ...metric.AddOption) {
+	if c.values == nil {
+		c.values = make(map[attribute.Distinct]int64)
 	}
+	attrs := metric.NewAddConfig(options).Attributes()
+	c.values[attrs.Equivalent()] += incr
 }
 
 type spanStub struct {
@@ -280,8 +483,10 @@ type spanStub struct {
 
 	events  map[string][]trace.EventOption
 	errors  map[error][]trace.EventOption
+	links   []trace.Link
 	status  codes.Code
 	message string
+	attrs   []attribute.KeyValue
 }
 
 func (s *spanStub) AddEvent(name string, options ...trace.EventOption) {
@@ -298,11 +503,19 @@ func (s *spanStub) RecordError(err error, options ...trace.EventOption) {
 	s.errors[err] = options
 }
 
+func (s *spanStub) AddLink(link trace.Link) {
+	s.links = append(s.links, link)
+}
+
 func (s *spanStub) SetStatus(status codes.Code, message string) {
 	s.status = status
 	s.message = message
 }
 
+func (s *spanStub) SetAttributes(kv ...attribute.KeyValue) {
+	s.attrs = append(s.attrs, kv...)
+}
+
 func (s *spanStub) IsRecording() bool {
 	return s.recording
 }
@@ -310,3 +523,456 @@ func (s *spanStub) IsRecording() bool {
 func (s *spanStub) SpanContext() trace.SpanContext {
 	return s.spanContext
 }
+
+func TestHandler_timeAsUnixNano(t *testing.T) {
+	t.Parallel()
+
+	span := sampledSpan()
+	ctx := trace.ContextWithSpan(context.Background(), span)
+
+	handler := otel.New(slog.NewTextHandler(&bytes.Buffer{}, nil),
+		otel.WithRecordEvent(false),
+		otel.WithTimeAsUnixNano(true),
+	)
+	assert.NoError(t, handler.Handle(ctx,
+		record(slog.LevelInfo, "msg", "time", time.Unix(100, 1000), "duration", time.Second)))
+
+	assert.Equal(t, []attribute.KeyValue{
+		attribute.Int64("time", 100000001000),
+		attribute.Int64("duration", 1000000000),
+	}, eventAttributes(span.events["msg"])[:2])
+}
+
+func TestHandler_maxAttributeValueLength(t *testing.T) {
+	t.Parallel()
+
+	span := sampledSpan()
+	ctx := trace.ContextWithSpan(context.Background(), span)
+
+	handler := otel.New(slog.NewTextHandler(&bytes.Buffer{}, nil),
+		otel.WithRecordEvent(false),
+		otel.WithMaxAttributeValueLength(5),
+	)
+	assert.NoError(t, handler.Handle(ctx,
+		record(slog.LevelInfo, "msg", "long", "abcdefghij", "exact", "abcde", "slice", []string{"abcdefghij"})))
+
+	assert.Equal(t, []attribute.KeyValue{
+		attribute.String("long", "abcd…"),
+		attribute.String("exact", "abcde"),
+		attribute.StringSlice("slice", []string{"abcdefghij"}),
+	}, eventAttributes(span.events["msg"])[:3])
+}
+
+func TestHandler_spanContextFunc(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	handler := otel.New(textHandler(buf),
+		otel.WithSpanContextFunc(func(context.Context) trace.SpanContext {
+			return sampledSpan().spanContext
+		}),
+	)
+	assert.NoError(t, handler.Handle(context.Background(), record(slog.LevelInfo, "msg")))
+
+	assert.Equal(t, "level=INFO msg=msg trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7 trace_flags=01\n",
+		buf.String())
+}
+
+func TestHandler_traceContext(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	handler := otel.New(textHandler(buf),
+		otel.WithTraceContext(func(context.Context) ([16]byte, [8]byte, byte) {
+			return [16]byte{75, 249, 47, 53, 119, 179, 77, 166, 163, 206, 146, 157, 14, 14, 71, 54},
+				[8]byte{0, 240, 103, 170, 11, 169, 2, 183},
+				1
+		}),
+	)
+	assert.NoError(t, handler.Handle(context.Background(), record(slog.LevelInfo, "msg")))
+	// The span context in the context takes precedence.
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: [16]byte{1},
+		SpanID:  [8]byte{1},
+	}))
+	assert.NoError(t, handler.Handle(ctx, record(slog.LevelInfo, "msg")))
+
+	assert.Equal(t, `level=INFO msg=msg trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7 trace_flags=01
+level=INFO msg=msg trace_id=01000000000000000000000000000000 span_id=0100000000000000 trace_flags=00
+`, buf.String())
+}
+
+func TestHandler_level(t *testing.T) {
+	t.Parallel()
+
+	level := &slog.LevelVar{}
+	level.Set(slog.LevelWarn)
+	handler := otel.New(textHandler(&bytes.Buffer{}), otel.WithLevel(level))
+	assert.Equal(t, false, handler.Enabled(context.Background(), slog.LevelInfo))
+	assert.Equal(t, true, handler.Enabled(context.Background(), slog.LevelWarn))
+
+	level.Set(slog.LevelDebug)
+	assert.Equal(t, true, handler.Enabled(context.Background(), slog.LevelInfo))
+}
+
+func TestHandler_levelAttribute(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		level       slog.Level
+		expected    attribute.KeyValue
+	}{
+		{
+			description: "info",
+			level:       slog.LevelInfo,
+			expected:    attribute.String("log.severity", "INFO"),
+		},
+		{
+			description: "warn",
+			level:       slog.LevelWarn,
+			expected:    attribute.String("log.severity", "WARN"),
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.description, func(t *testing.T) {
+			t.Parallel()
+
+			span := sampledSpan()
+			ctx := trace.ContextWithSpan(context.Background(), span)
+
+			handler := otel.New(slog.NewTextHandler(&bytes.Buffer{}, nil),
+				otel.WithRecordEvent(false),
+				otel.WithLevelAttribute(true),
+			)
+			assert.NoError(t, handler.Handle(ctx, record(testcase.level, "msg")))
+
+			attrs := eventAttributes(span.events["msg"])
+			assert.Equal(t, testcase.expected, attrs[len(attrs)-1])
+		})
+	}
+}
+
+func TestHandler_link(t *testing.T) {
+	t.Parallel()
+
+	span := sampledSpan()
+	ctx := trace.ContextWithSpan(context.Background(), span)
+
+	handler := otel.New(slog.NewTextHandler(&bytes.Buffer{}, nil), otel.WithRecordEvent(false))
+	assert.NoError(t, handler.Handle(ctx, record(slog.LevelInfo, "msg",
+		slog.Group(otel.LinkKey,
+			otel.TraceKey, "0102030405060708090a0b0c0d0e0f10",
+			otel.SpanKey, "0102030405060708",
+			otel.TraceFlagsKey, "01",
+		),
+	)))
+
+	assert.Equal(t, []trace.Link{
+		{
+			SpanContext: trace.NewSpanContext(trace.SpanContextConfig{
+				TraceID:    [16]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+				SpanID:     [8]byte{1, 2, 3, 4, 5, 6, 7, 8},
+				TraceFlags: trace.TraceFlags(1),
+			}),
+		},
+	}, span.links)
+	assert.Equal(t, 3, len(eventAttributes(span.events["msg"])))
+}
+
+func TestHandler_eventReplaceAttr(t *testing.T) {
+	t.Parallel()
+
+	span := sampledSpan()
+	ctx := trace.ContextWithSpan(context.Background(), span)
+
+	buf := &bytes.Buffer{}
+	handler := otel.New(textHandler(buf),
+		otel.WithRecordEvent(true),
+		otel.WithEventReplaceAttr(func(groups []string, attr slog.Attr) slog.Attr {
+			if len(groups) == 1 && groups[0] == "db" && attr.Key == "sql" {
+				return slog.Attr{}
+			}
+			if attr.Key == "user" {
+				attr.Key = "enduser.id"
+			}
+
+			return attr
+		}),
+	)
+	record := record(slog.LevelInfo, "query")
+	record.AddAttrs(slog.Group("db", slog.String("sql", "select 1"), slog.Int("rows", 1)), slog.String("user", "sloth"))
+	assert.NoError(t, handler.Handle(ctx, record))
+
+	assert.Equal(t, []attribute.KeyValue{
+		attribute.Int64("db.rows", 1),
+		attribute.String("enduser.id", "sloth"),
+	}, eventAttributes(span.events["query"])[:2])
+	assert.Equal(t, true, strings.Contains(buf.String(), "db.sql="))
+}
+
+func TestHandler_eventAttributes(t *testing.T) {
+	t.Parallel()
+
+	span := sampledSpan()
+	ctx := trace.ContextWithSpan(context.Background(), span)
+
+	buf := &bytes.Buffer{}
+	handler := otel.New(textHandler(buf),
+		otel.WithRecordEvent(true),
+		otel.WithEventAttributes(attribute.String("service.name", "test"), attribute.String("cloud.region", "us")),
+	)
+	assert.NoError(t, handler.Handle(ctx, record(slog.LevelInfo, "msg")))
+	assert.NoError(t, handler.Handle(ctx, record(slog.LevelError, "error")))
+
+	attrs := eventAttributes(span.events["msg"])
+	assert.Equal(t, []attribute.KeyValue{
+		attribute.String("service.name", "test"),
+		attribute.String("cloud.region", "us"),
+	}, attrs[len(attrs)-2:])
+	for _, options := range span.errors {
+		attrs = eventAttributes(options)
+		assert.Equal(t, attribute.String("cloud.region", "us"), attrs[len(attrs)-1])
+	}
+	assert.Equal(t, false, strings.Contains(buf.String(), "region"))
+}
+
+func TestHandler_spanAttributeKeys(t *testing.T) {
+	t.Parallel()
+
+	span := sampledSpan()
+	ctx := trace.ContextWithSpan(context.Background(), span)
+
+	handler := otel.New(slog.NewTextHandler(&bytes.Buffer{}, nil),
+		otel.WithRecordEvent(true),
+		otel.WithSpanAttributeKeys("user_id", "g.tenant"),
+	).WithAttrs([]slog.Attr{slog.String("user_id", "u1")}).WithGroup("g")
+	assert.NoError(t, handler.Handle(ctx, record(slog.LevelInfo, "msg", "tenant", "t1", "a", "A")))
+
+	assert.Equal(t, []attribute.KeyValue{
+		attribute.String("user_id", "u1"),
+		attribute.String("g.tenant", "t1"),
+	}, span.attrs)
+	attrs := eventAttributes(span.events["msg"])
+	assert.Equal(t, []attribute.KeyValue{
+		attribute.String("user_id", "u1"),
+		attribute.String("g.tenant", "t1"),
+		attribute.String("g.a", "A"),
+	}, attrs[:3])
+}
+
+func TestHandler_callerSkip(t *testing.T) {
+	t.Parallel()
+
+	span := sampledSpan()
+	ctx := trace.ContextWithSpan(context.Background(), span)
+
+	handler := otel.New(slog.NewTextHandler(&bytes.Buffer{}, nil),
+		otel.WithRecordEvent(true),
+		otel.WithCallerSkip(1),
+	)
+	logf(t, handler, ctx, "msg")
+
+	attrs := eventAttributes(span.events["msg"])
+	assert.Equal(t,
+		semconv.CodeFunction("github.com/nil-go/sloth/otel_test.TestHandler_callerSkip"),
+		attrs[len(attrs)-1],
+	)
+}
+
+// logf is a thin wrapper which logs the record with the PC of itself, like a log.Printf bridge.
+func logf(t *testing.T, handler slog.Handler, ctx context.Context, message string) {
+	t.Helper()
+
+	var pcs [1]uintptr
+	runtime.Callers(1, pcs[:])
+	assert.NoError(t, handler.Handle(ctx, slog.NewRecord(time.Unix(100, 1000), slog.LevelInfo, message, pcs[0])))
+}
+
+func TestHandler_emptyGroup(t *testing.T) {
+	t.Parallel()
+
+	span1, span2 := sampledSpan(), sampledSpan()
+	buf1, buf2 := &bytes.Buffer{}, &bytes.Buffer{}
+	attrs := []slog.Attr{slog.String("a", "A")}
+	rec := record(slog.LevelInfo, "msg", "b", "B")
+	assert.NoError(t, otel.New(textHandler(buf1), otel.WithRecordEvent(true)).WithGroup("").WithAttrs(attrs).
+		Handle(trace.ContextWithSpan(context.Background(), span1), rec))
+	assert.NoError(t, otel.New(textHandler(buf2), otel.WithRecordEvent(true)).WithAttrs(attrs).
+		Handle(trace.ContextWithSpan(context.Background(), span2), rec))
+
+	assert.Equal(t, buf2.String(), buf1.String())
+	assert.Equal(t, eventAttributes(span2.events["msg"]), eventAttributes(span1.events["msg"]))
+}
+
+func TestHandler_exceptionType(t *testing.T) {
+	t.Parallel()
+
+	span := sampledSpan()
+	ctx := trace.ContextWithSpan(context.Background(), span)
+
+	handler := otel.NewEventOnly()
+	assert.NoError(t, handler.Handle(ctx, record(slog.LevelError, "failed",
+		"first", timeoutError{}, "second", errors.New("an error"),
+	)))
+
+	assert.Equal(t, 1, len(span.errors))
+	for err, options := range span.errors {
+		assert.Equal(t, "failed: timeout\nan error", err.Error())
+		attrs := eventAttributes(options)
+		assert.Equal(t, semconv.ExceptionType("github.com/nil-go/sloth/otel_test.timeoutError"), attrs[len(attrs)-1])
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string {
+	return "timeout"
+}
+
+func TestHandler_spanName(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	logger := slog.New(otel.New(textHandler(buf), otel.WithSpanName(true)))
+	span := namedSpan{spanStub: sampledSpan(), name: "GET /"}
+	logger.InfoContext(trace.ContextWithSpan(context.Background(), span), "named")
+	logger.InfoContext(trace.ContextWithSpan(context.Background(), sampledSpan()), "unnamed")
+
+	assert.Equal(t, `level=INFO msg=named trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7 trace_flags=01 span_name="GET /"
+level=INFO msg=unnamed trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7 trace_flags=01
+`, buf.String())
+}
+
+type namedSpan struct {
+	*spanStub
+
+	name string
+}
+
+func (s namedSpan) Name() string {
+	return s.name
+}
+
+func TestHandler_eventLevel(t *testing.T) {
+	t.Parallel()
+
+	span := sampledSpan()
+	ctx := trace.ContextWithSpan(context.Background(), span)
+
+	buf := &bytes.Buffer{}
+	logger := slog.New(otel.New(textHandler(buf),
+		otel.WithRecordEvent(true),
+		otel.WithEventLevel(slog.LevelDebug),
+	))
+	logger.DebugContext(ctx, "debug")
+	logger.InfoContext(ctx, "info")
+	logger.Debug("no span")
+
+	assert.Equal(t, 2, len(span.events))
+	assert.Equal(t, true, span.events["debug"] != nil)
+	assert.Equal(t, `level=INFO msg=info trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7 trace_flags=01
+`, buf.String())
+}
+
+func TestHandler_separateExceptions(t *testing.T) {
+	t.Parallel()
+
+	span := sampledSpan()
+	ctx := trace.ContextWithSpan(context.Background(), span)
+
+	handler := otel.NewEventOnly(otel.WithSeparateExceptions(true))
+	assert.NoError(t, handler.Handle(ctx, record(slog.LevelError, "failed",
+		"first", timeoutError{}, "second", errors.New("an error"),
+	)))
+
+	assert.Equal(t, 2, len(span.errors))
+	for err, options := range span.errors {
+		attrs := eventAttributes(options)
+		switch err.Error() {
+		case "failed: timeout":
+			assert.Equal(t, semconv.ExceptionType("github.com/nil-go/sloth/otel_test.timeoutError"), attrs[len(attrs)-1])
+		case "failed: an error":
+			assert.Equal(t, semconv.ExceptionType("*errors.errorString"), attrs[len(attrs)-1])
+		default:
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	assert.Equal(t, codes.Error, span.status)
+	assert.Equal(t, "failed", span.message)
+}
+
+func TestNewEventOnly(t *testing.T) {
+	t.Parallel()
+
+	span := sampledSpan()
+	ctx := trace.ContextWithSpan(context.Background(), span)
+
+	handler := otel.NewEventOnly()
+	assert.Equal(t, true, handler.Enabled(ctx, slog.LevelInfo))
+	assert.Equal(t, false, handler.Enabled(context.Background(), slog.LevelInfo))
+
+	logger := slog.New(handler.WithAttrs([]slog.Attr{slog.String("a", "A")}).WithGroup("g"))
+	logger.InfoContext(ctx, "msg", "b", "B")
+	logger.ErrorContext(ctx, "error", "error", errors.New("an error"))
+	logger.InfoContext(context.Background(), "no span")
+
+	assert.Equal(t, []attribute.KeyValue{
+		attribute.String("a", "A"),
+		attribute.String("g.b", "B"),
+	}, eventAttributes(span.events["msg"])[:2])
+	assert.Equal(t, 1, len(span.events))
+	assert.Equal(t, 1, len(span.errors))
+	assert.Equal(t, codes.Error, span.status)
+}
+
+func TestHandler_metricCounter(t *testing.T) {
+	t.Parallel()
+
+	span := sampledSpan()
+	ctx := trace.ContextWithSpan(context.Background(), span)
+
+	counter := &counterStub{}
+	handler := otel.New(slog.NewTextHandler(&bytes.Buffer{}, nil),
+		otel.WithRecordEvent(false),
+		otel.WithMetricCounter(counter),
+	)
+	assert.NoError(t, handler.Handle(ctx, record(slog.LevelError, "msg")))
+	assert.NoError(t, handler.Handle(ctx, record(slog.LevelError, "another msg")))
+	assert.NoError(t, handler.Handle(ctx, record(slog.LevelWarn, "msg")))
+
+	// Records are counted by level regardless of the message.
+	errorAttrs := attribute.NewSet(attribute.String("log.severity", "ERROR"))
+	warnAttrs := attribute.NewSet(attribute.String("log.severity", "WARN"))
+	assert.Equal(t, map[attribute.Distinct]int64{errorAttrs.Equivalent(): 2, warnAttrs.Equivalent(): 1}, counter.values)
+}
+
+func textHandler(buf *bytes.Buffer) slog.Handler {
+	return slog.NewTextHandler(buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if len(groups) == 0 && attr.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+
+			return attr
+		},
+	})
+}
+
+func sampledSpan() *spanStub {
+	return &spanStub{
+		recording: true,
+		spanContext: trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    [16]byte{75, 249, 47, 53, 119, 179, 77, 166, 163, 206, 146, 157, 14, 14, 71, 54},
+			SpanID:     [8]byte{0, 240, 103, 170, 11, 169, 2, 183},
+			TraceFlags: trace.TraceFlags(1),
+		}),
+	}
+}
+
+func eventAttributes(options []trace.EventOption) []attribute.KeyValue {
+	config := trace.NewEventConfig(options...)
+
+	return config.Attributes()
+}
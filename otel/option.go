@@ -3,6 +3,15 @@
 
 package otel
 
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
 // WithRecordEvent enables recording log records as trace span's events.
 // If passThrough is true, the log record will pass through to the next handler.
 //
@@ -15,6 +24,188 @@ func WithRecordEvent(passThrough bool) Option {
 	}
 }
 
+// WithRecordUnsampled records log records as events while the span is recording
+// even if the trace is not sampled, e.g. spans recorded locally for tail sampling.
+//
+// By default, log records are recorded as events only if the span is recording and sampled.
+func WithRecordUnsampled(recordUnsampled bool) Option {
+	return func(options *options) {
+		options.eventHandler.recordUnsampled = recordUnsampled
+	}
+}
+
+// WithMetricCounter increments the given counter for each log record recorded as event,
+// with the level as attribute `log.severity`. The message is not an attribute since it has unbounded cardinality.
+// Since the context carries the span, the metric SDK could correlate the counter with the trace as exemplar.
+func WithMetricCounter(counter metric.Int64Counter) Option {
+	return func(options *options) {
+		options.eventHandler.counter = counter
+	}
+}
+
+// WithTimeAsUnixNano emits time and duration values as int64 nanoseconds in recorded events
+// rather than strings, which some backends index better for range queries.
+//
+// By default, time values are formatted as RFC3339Nano and duration values as Duration.String.
+func WithTimeAsUnixNano(unixNano bool) Option {
+	return func(options *options) {
+		options.eventHandler.timeAsUnixNano = unixNano
+	}
+}
+
+// WithAttributeSeparator provides the separator that joins group names and attribute keys
+// in recorded events, e.g. `g.h.error` for the default separator.
+//
+// If the separator is empty, the handler assumes ".".
+func WithAttributeSeparator(separator string) Option {
+	return func(options *options) {
+		options.eventHandler.separator = separator
+	}
+}
+
+// WithMaxAttributeValueLength limits the length of string attribute values in recorded events
+// to prevent them from being dropped by span attribute limits.
+// Longer values are truncated to n characters, ending with an ellipsis.
+// It does not apply to slices or numeric values.
+//
+// If n is <= 0, the handler does not truncate values.
+func WithMaxAttributeValueLength(n int) Option {
+	return func(options *options) {
+		options.eventHandler.maxValueLength = n
+	}
+}
+
+// WithSpanContextFunc provides a function to get the span context for trace attributes,
+// e.g. when the active span context is stored under a different context key.
+//
+// If it is nil, the handler uses trace.SpanContextFromContext.
+func WithSpanContextFunc(spanContext func(context.Context) trace.SpanContext) Option {
+	return func(options *options) {
+		options.spanContext = spanContext
+	}
+}
+
+// WithTraceContext provides the [W3C Trace Context] if there is no valid span context in the context,
+// e.g. a worker which receives serialized trace parents over a queue without an active span.
+//
+// If it is nil, the handler only uses the span context from the context.
+//
+// [W3C Trace Context]: https://www.w3.org/TR/trace-context/#traceparent-header-field-values
+func WithTraceContext(provider func(context.Context) (traceID [16]byte, spanID [8]byte, traceFlags byte)) Option {
+	return func(options *options) {
+		options.traceContext = provider
+	}
+}
+
+// WithOnlySampledTrace only adds trace attributes to log records if the span is sampled,
+// which avoids correlating logs with unsampled traces.
+//
+// By default, trace attributes are added for any valid span context.
+func WithOnlySampledTrace(onlySampled bool) Option {
+	return func(options *options) {
+		options.onlySampledTrace = onlySampled
+	}
+}
+
+// WithTraceAttributes adds trace attributes, e.g. TraceKey, to log records passed to the wrapped handler,
+// which could be disabled if the downstream exporter already injects the trace context.
+// It does not affect recording events.
+//
+// By default, trace attributes are added.
+func WithTraceAttributes(traceAttributes bool) Option {
+	return func(options *options) {
+		options.noTraceAttributes = !traceAttributes
+	}
+}
+
+// WithSpanName adds the name of the span in the context as attribute SpanNameKey to log records
+// passed to the wrapped handler, so logs could be correlated with the specific span.
+// The attribute is omitted if the span does not expose its name, e.g. spans not created by the SDK.
+func WithSpanName(spanName bool) Option {
+	return func(options *options) {
+		options.spanName = spanName
+	}
+}
+
+// WithLevelAttribute adds the level of the log record as attribute `log.severity`
+// to recorded events, so backends could distinguish the severity of events.
+func WithLevelAttribute(levelAttribute bool) Option {
+	return func(options *options) {
+		options.eventHandler.levelAttribute = levelAttribute
+	}
+}
+
+// WithEventReplaceAttr provides a function to rewrite each non-group attribute before it's converted
+// to the event attribute, like slog.HandlerOptions.ReplaceAttr, e.g. dropping a verbose attribute from events
+// while keeping it in logs. The attribute is dropped if the function returns the zero Attr.
+// It does not apply to log records passed to the wrapped handler.
+func WithEventReplaceAttr(replaceAttr func(groups []string, attr slog.Attr) slog.Attr) Option {
+	return func(options *options) {
+		options.eventHandler.replaceAttr = replaceAttr
+	}
+}
+
+// WithEventAttributes provides attributes added to every recorded event, e.g. service name and region,
+// which are not added to log records passed to the wrapped handler.
+func WithEventAttributes(attrs ...attribute.KeyValue) Option {
+	return func(options *options) {
+		options.eventHandler.constAttrs = append(options.eventHandler.constAttrs, attrs...)
+	}
+}
+
+// WithLevel provides the minimum level of log records the handler handles,
+// independent of the level of the wrapped handler.
+// Log records below the level are dropped entirely, neither logged nor recorded as events.
+//
+// By default, the handler has no minimum level and delegates to the wrapped handler.
+func WithLevel(level slog.Leveler) Option {
+	return func(options *options) {
+		options.level = level
+	}
+}
+
+// WithSeparateExceptions records each error attribute of log records with slog.LevelError and above
+// as a separate exception event of the span, instead of a single exception joined from all errors.
+// The status of the span is still set to error once.
+func WithSeparateExceptions(separate bool) Option {
+	return func(options *options) {
+		options.eventHandler.separateExceptions = separate
+	}
+}
+
+// WithEventLevel provides the minimum level of log records recorded as events while WithRecordEvent has been called,
+// even if the wrapped handler is not enabled for them, e.g. recording debug logs as events
+// while only writing info logs. Log records not enabled by the wrapped handler are not passed to it.
+//
+// By default, only log records enabled by the wrapped handler are recorded as events.
+func WithEventLevel(level slog.Leveler) Option {
+	return func(options *options) {
+		options.eventLevel = level
+	}
+}
+
+// WithSpanAttributeKeys provides keys of attributes which are also set as attributes of the span
+// while WithRecordEvent has been called, e.g. `user_id` so spans are searchable by it.
+// Attributes in groups are matched by the key qualified with the group names and the separator.
+// Other attributes are only added to the event.
+func WithSpanAttributeKeys(keys ...string) Option {
+	return func(options *options) {
+		options.eventHandler.spanAttributeKeys = append(options.eventHandler.spanAttributeKeys, keys...)
+	}
+}
+
+// WithCallerSkip provides the number of frames to skip from the frame of the record
+// for code attributes of events, e.g. 1 if records are logged through a thin wrapper like a log.Printf bridge.
+// It re-walks the stack of the calling goroutine, so it has performance cost,
+// and the frame of the record is used if it is not on the stack, e.g. the record is handled asynchronously.
+//
+// If the number is <= 0, the handler uses the frame of the record.
+func WithCallerSkip(n int) Option {
+	return func(options *options) {
+		options.eventHandler.callerSkip = n
+	}
+}
+
 type (
 	// Option configures the Handler with specific options.
 	Option  func(*options)
@@ -0,0 +1,66 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+package otel
+
+import (
+	"context"
+	"math/rand/v2"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TraceSampler samples records according to the sampling decision of the span in the context.
+// It could be used as the sampler of sampling.Handler so logs and traces are consistently sampled.
+//
+// If there is no valid span context, it returns true.
+func TraceSampler(ctx context.Context) bool {
+	spanContext := trace.SpanContextFromContext(ctx)
+
+	return !spanContext.IsValid() || spanContext.IsSampled()
+}
+
+// TraceSamplerStrict samples records according to the sampling decision of the span in the context
+// like TraceSampler, but returns false if there is no valid span context,
+// so untraced requests, e.g. background jobs, are also subject to sampling.
+func TraceSamplerStrict(ctx context.Context) bool {
+	return trace.SpanContextFromContext(ctx).IsSampled()
+}
+
+// ProbabilitySampler returns a sampler which samples records with the given probability.
+// The probability p should be in range [0, 1].
+//
+// It is safe for concurrent use.
+func ProbabilitySampler(p float64) func(context.Context) bool {
+	return func(context.Context) bool {
+		return rand.Float64() < p //nolint:gosec // It does not need crypto random for sampling.
+	}
+}
+
+// AnySampler returns a sampler which samples records if any of the given samplers samples it.
+// The samplers are evaluated in order, and it stops at the first sampler that samples the record.
+func AnySampler(samplers ...func(context.Context) bool) func(context.Context) bool {
+	return func(ctx context.Context) bool {
+		for _, sampler := range samplers {
+			if sampler(ctx) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// AllSampler returns a sampler which samples records only if all the given samplers sample it.
+// The samplers are evaluated in order, and it stops at the first sampler that does not sample the record.
+func AllSampler(samplers ...func(context.Context) bool) func(context.Context) bool {
+	return func(ctx context.Context) bool {
+		for _, sampler := range samplers {
+			if !sampler(ctx) {
+				return false
+			}
+		}
+
+		return true
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+package otel_test
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/nil-go/sloth/otel"
+	"github.com/nil-go/sloth/otel/internal/assert"
+)
+
+func TestSampler(t *testing.T) {
+	t.Parallel()
+
+	sampled := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    [16]byte{75, 249, 47, 53, 119, 179, 77, 166, 163, 206, 146, 157, 14, 14, 71, 54},
+		SpanID:     [8]byte{0, 240, 103, 170, 11, 169, 2, 183},
+		TraceFlags: trace.TraceFlags(1),
+	}))
+	unsampled := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: [16]byte{75, 249, 47, 53, 119, 179, 77, 166, 163, 206, 146, 157, 14, 14, 71, 54},
+		SpanID:  [8]byte{0, 240, 103, 170, 11, 169, 2, 183},
+	}))
+
+	testcases := []struct {
+		description string
+		sampler     func(context.Context) bool
+		ctx         context.Context //nolint:containedctx
+		expected    bool
+	}{
+		{
+			description: "trace (sampled)",
+			sampler:     otel.TraceSampler,
+			ctx:         sampled,
+			expected:    true,
+		},
+		{
+			description: "trace (unsampled)",
+			sampler:     otel.TraceSampler,
+			ctx:         unsampled,
+		},
+		{
+			description: "trace (no span)",
+			sampler:     otel.TraceSampler,
+			ctx:         context.Background(),
+			expected:    true,
+		},
+		{
+			description: "trace strict (sampled)",
+			sampler:     otel.TraceSamplerStrict,
+			ctx:         sampled,
+			expected:    true,
+		},
+		{
+			description: "trace strict (unsampled)",
+			sampler:     otel.TraceSamplerStrict,
+			ctx:         unsampled,
+		},
+		{
+			description: "trace strict (no span)",
+			sampler:     otel.TraceSamplerStrict,
+			ctx:         context.Background(),
+		},
+		{
+			description: "probability (always)",
+			sampler:     otel.ProbabilitySampler(1),
+			ctx:         unsampled,
+			expected:    true,
+		},
+		{
+			description: "probability (never)",
+			sampler:     otel.ProbabilitySampler(0),
+			ctx:         sampled,
+		},
+		{
+			description: "any (sampled trace)",
+			sampler:     otel.AnySampler(otel.TraceSampler, otel.ProbabilitySampler(0)),
+			ctx:         sampled,
+			expected:    true,
+		},
+		{
+			description: "any (unsampled trace)",
+			sampler:     otel.AnySampler(otel.TraceSampler, otel.ProbabilitySampler(0)),
+			ctx:         unsampled,
+		},
+		{
+			description: "any (unsampled trace with probability)",
+			sampler:     otel.AnySampler(otel.TraceSampler, otel.ProbabilitySampler(1)),
+			ctx:         unsampled,
+			expected:    true,
+		},
+		{
+			description: "any (empty)",
+			sampler:     otel.AnySampler(),
+			ctx:         sampled,
+		},
+		{
+			description: "all (sampled trace)",
+			sampler:     otel.AllSampler(otel.TraceSampler, otel.ProbabilitySampler(1)),
+			ctx:         sampled,
+			expected:    true,
+		},
+		{
+			description: "all (sampled trace without probability)",
+			sampler:     otel.AllSampler(otel.TraceSampler, otel.ProbabilitySampler(0)),
+			ctx:         sampled,
+		},
+		{
+			description: "all (unsampled trace)",
+			sampler:     otel.AllSampler(otel.TraceSampler, otel.ProbabilitySampler(1)),
+			ctx:         unsampled,
+		},
+		{
+			description: "all (empty)",
+			sampler:     otel.AllSampler(),
+			ctx:         unsampled,
+			expected:    true,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.description, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, testcase.expected, testcase.sampler(testcase.ctx))
+		})
+	}
+}
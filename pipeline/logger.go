@@ -0,0 +1,56 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+/*
+Package pipeline provides a helper to build a slog.Logger with the standard stack of sloth handlers,
+so handlers are composed in the right order.
+
+The stages are composed in the following order, from the outermost to the innermost:
+
+  - rate, which limits records before any other work is done for them;
+  - sampling, which samples records at request level;
+  - middlewares, e.g. the otel handler, in the order they are provided;
+  - gcp (or the handler provided by WithHandler), which writes records to the final destination.
+
+Each stage except the final handler is disabled unless its option is provided.
+
+	logger := pipeline.NewLogger(
+		pipeline.WithRate(rate.WithFirst(10)),
+		pipeline.WithSampling(sampler),
+		pipeline.WithMiddleware(func(handler slog.Handler) slog.Handler { return otel.New(handler) }),
+		pipeline.WithGCP(gcp.WithTrace(project)),
+	)
+*/
+package pipeline
+
+import (
+	"log/slog"
+
+	"github.com/nil-go/sloth/gcp"
+	"github.com/nil-go/sloth/rate"
+	"github.com/nil-go/sloth/sampling"
+)
+
+// NewLogger creates a new slog.Logger with the handler stack built with the given Option(s).
+func NewLogger(opts ...Option) *slog.Logger {
+	option := &options{}
+	for _, opt := range opts {
+		opt(option)
+	}
+
+	handler := option.handler
+	if handler == nil {
+		handler = gcp.New(option.gcpOpts...)
+	}
+	for i := len(option.middlewares) - 1; i >= 0; i-- {
+		handler = option.middlewares[i](handler)
+	}
+	if option.sampler != nil {
+		handler = sampling.New(handler, option.sampler, option.samplingOpts...)
+	}
+	if option.rate {
+		handler = rate.New(handler, option.rateOpts...)
+	}
+
+	return slog.New(handler)
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+package pipeline_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nil-go/sloth/gcp"
+	"github.com/nil-go/sloth/internal/assert"
+	"github.com/nil-go/sloth/pipeline"
+	"github.com/nil-go/sloth/rate"
+)
+
+func TestNewLogger(t *testing.T) {
+	t.Parallel()
+
+	var stages []string
+	stage := func(name string) func(slog.Handler) slog.Handler {
+		return func(handler slog.Handler) slog.Handler {
+			return stageHandler{Handler: handler, name: name, stages: &stages}
+		}
+	}
+	buf := &bytes.Buffer{}
+	logger := pipeline.NewLogger(
+		pipeline.WithRate(
+			rate.WithFirst(1),
+			rate.WithEvery(0),
+			rate.WithOnDrop(func(context.Context, slog.Record) { stages = append(stages, "rate") }),
+		),
+		pipeline.WithSampling(func(context.Context) bool {
+			stages = append(stages, "sampling")
+
+			return true
+		}),
+		pipeline.WithMiddleware(stage("first")),
+		pipeline.WithMiddleware(stage("second")),
+		pipeline.WithHandler(slog.NewTextHandler(buf, &slog.HandlerOptions{
+			ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+				if len(groups) == 0 && attr.Key == slog.TimeKey {
+					return slog.Attr{}
+				}
+
+				return attr
+			},
+		})),
+	)
+
+	ctx := context.Background()
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, "msg", 0)
+	assert.NoError(t, logger.Handler().Handle(ctx, record))
+	assert.NoError(t, logger.Handler().Handle(ctx, record))
+
+	assert.Equal(t, []string{"sampling", "first", "second", "rate"}, stages)
+	assert.Equal(t, "level=INFO msg=msg\n", buf.String())
+}
+
+type stageHandler struct {
+	slog.Handler
+	name   string
+	stages *[]string
+}
+
+func (h stageHandler) Handle(ctx context.Context, record slog.Record) error {
+	*h.stages = append(*h.stages, h.name)
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func TestNewLogger_gcp(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	logger := pipeline.NewLogger(pipeline.WithGCP(gcp.WithWriter(buf)))
+	logger.Info("msg")
+
+	assert.Equal(t, true, strings.Contains(buf.String(), `"severity":"INFO"`))
+	assert.Equal(t, true, strings.Contains(buf.String(), `"message":"msg"`))
+}
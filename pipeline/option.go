@@ -0,0 +1,75 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+package pipeline
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/nil-go/sloth/gcp"
+	"github.com/nil-go/sloth/rate"
+	"github.com/nil-go/sloth/sampling"
+)
+
+// WithRate enables the rate stage with the given rate.Option(s).
+func WithRate(opts ...rate.Option) Option {
+	return func(options *options) {
+		options.rate = true
+		options.rateOpts = append(options.rateOpts, opts...)
+	}
+}
+
+// WithSampling enables the sampling stage with the given sampler and sampling.Option(s).
+//
+// If the sampler is nil, the sampling stage is disabled.
+func WithSampling(sampler func(context.Context) bool, opts ...sampling.Option) Option {
+	return func(options *options) {
+		options.sampler = sampler
+		options.samplingOpts = append(options.samplingOpts, opts...)
+	}
+}
+
+// WithMiddleware adds the middleware which wraps the final handler, e.g. the otel handler.
+// Middlewares are applied in the order they are provided, so the first one is the outermost.
+func WithMiddleware(middleware func(slog.Handler) slog.Handler) Option {
+	return func(options *options) {
+		if middleware != nil {
+			options.middlewares = append(options.middlewares, middleware)
+		}
+	}
+}
+
+// WithGCP provides gcp.Option(s) for the final gcp handler.
+func WithGCP(opts ...gcp.Option) Option {
+	return func(options *options) {
+		options.gcpOpts = append(options.gcpOpts, opts...)
+	}
+}
+
+// WithHandler provides the final handler which writes records to the destination,
+// e.g. a text handler for local development. It takes precedence over WithGCP.
+//
+// If it is nil, the logger uses the gcp handler.
+func WithHandler(handler slog.Handler) Option {
+	return func(options *options) {
+		options.handler = handler
+	}
+}
+
+type (
+	// Option configures the logger with specific options.
+	Option  func(*options)
+	options struct {
+		rate     bool
+		rateOpts []rate.Option
+
+		sampler      func(context.Context) bool
+		samplingOpts []sampling.Option
+
+		middlewares []func(slog.Handler) slog.Handler
+
+		handler slog.Handler
+		gcpOpts []gcp.Option
+	}
+)
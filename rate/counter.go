@@ -4,7 +4,9 @@
 package rate
 
 import (
+	"container/list"
 	"log/slog"
+	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -15,14 +17,81 @@ const (
 	levels           = (slog.LevelError-slog.LevelDebug)/gapPerLevel + 1
 )
 
-// Use array instead of map to reduce memory allocation and improve performance.
-type counters [levels][countersPerLevel]counter // size:256KiB
+type store interface {
+	get(level slog.Level, key string) *counter
+	// drops returns the drop counter for the level and key, which is only used
+	// by WithReportDropped and WithDropSample, so it could be allocated on demand.
+	drops(level slog.Level, key string) *dropCounter
+	reset()
+}
+
+// Counters keeps the rate-limit state, which could be shared by independently constructed handlers
+// with WithSharedCounters, e.g. handlers for the same logical subsystem.
+//
+// To create new Counters, call [NewCounters].
+type Counters struct {
+	store store
+}
+
+// NewCounters creates new Counters with the given number of counters per level which keys are hashed into.
+//
+// If the number is <= 0, it assumes 4096.
+func NewCounters(slots int) *Counters {
+	if slots <= 0 {
+		slots = countersPerLevel
+	}
+
+	return &Counters{store: newCounters(slots)}
+}
+
+// Use slice instead of map to reduce memory allocation and improve performance.
+// The default size is 256KiB with 4096 counters per level,
+// and drop counters take the same size once they are used.
+type counters struct {
+	slots        uint32
+	counters     []counter
+	dropCounters atomic.Pointer[[]dropCounter]
+}
+
+func newCounters(slots int) *counters {
+	return &counters{
+		slots:    uint32(slots), //nolint:gosec // slots is always positive.
+		counters: make([]counter, int(levels)*slots),
+	}
+}
 
 func (c *counters) get(level slog.Level, key string) *counter {
-	i := (min(slog.LevelDebug, max(slog.LevelError, level)) - slog.LevelDebug) / gapPerLevel
-	j := fnv32a(key) % countersPerLevel
+	return &c.counters[c.index(level, key)]
+}
+
+func (c *counters) drops(level slog.Level, key string) *dropCounter {
+	dropCounters := c.dropCounters.Load()
+	if dropCounters == nil {
+		allocated := make([]dropCounter, len(c.counters))
+		// Other goroutines might allocate drop counters at the same time, and only one of them wins.
+		c.dropCounters.CompareAndSwap(nil, &allocated)
+		dropCounters = c.dropCounters.Load()
+	}
 
-	return &c[i][j]
+	return &(*dropCounters)[c.index(level, key)]
+}
+
+func (c *counters) index(level slog.Level, key string) uint32 {
+	i := uint32((max(slog.LevelDebug, min(slog.LevelError, level)) - slog.LevelDebug) / gapPerLevel)
+	j := fnv32a(key) % c.slots
+
+	return i*c.slots + j
+}
+
+func (c *counters) reset() {
+	for i := range c.counters {
+		c.counters[i].reset()
+	}
+	if dropCounters := c.dropCounters.Load(); dropCounters != nil {
+		for i := range *dropCounters {
+			(*dropCounters)[i].reset()
+		}
+	}
 }
 
 func fnv32a(str string) uint32 {
@@ -39,26 +108,133 @@ func fnv32a(str string) uint32 {
 	return hash
 }
 
+// lruCounters keeps counters for exact keys, and evicts the least recently used counter
+// once it reaches the capacity. It avoids hash collisions of counters at the cost of speed.
+type lruCounters struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[lruKey]*list.Element
+	order    *list.List
+}
+
+type (
+	lruKey struct {
+		level slog.Level
+		key   string
+	}
+	lruEntry struct {
+		key         lruKey
+		counter     counter
+		dropCounter dropCounter
+	}
+)
+
+func newLRUCounters(capacity int) *lruCounters {
+	return &lruCounters{
+		capacity: capacity,
+		entries:  make(map[lruKey]*list.Element, capacity),
+		order:    list.New(),
+	}
+}
+
+func (c *lruCounters) get(level slog.Level, key string) *counter {
+	return &c.entry(level, key).counter
+}
+
+func (c *lruCounters) drops(level slog.Level, key string) *dropCounter {
+	return &c.entry(level, key).dropCounter
+}
+
+func (c *lruCounters) entry(level slog.Level, key string) *lruEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	k := lruKey{level: level, key: key}
+	if element, ok := c.entries[k]; ok {
+		c.order.MoveToFront(element)
+
+		return element.Value.(*lruEntry) //nolint:forcetypeassert
+	}
+
+	if c.order.Len() >= c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).key) //nolint:forcetypeassert
+	}
+	entry := &lruEntry{key: k}
+	c.entries[k] = c.order.PushFront(entry)
+
+	return entry
+}
+
+func (c *lruCounters) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	clear(c.entries)
+	c.order.Init()
+}
+
 type counter struct {
 	resetAt atomic.Int64
 	counter atomic.Uint64
 }
 
-func (c *counter) Inc(t time.Time, interval time.Duration) uint64 {
-	now := t.UnixNano()
+func (c *counter) reset() {
+	c.resetAt.Store(0)
+	c.counter.Store(0)
+}
+
+// dropCounter counts dropped records for WithReportDropped and WithDropSample.
+type dropCounter struct {
+	dropped atomic.Uint64
+	// The number of dropped records for WithDropSample, which is not reset by WithReportDropped.
+	droppedTotal atomic.Uint64
+}
+
+func (c *dropCounter) reset() {
+	c.dropped.Store(0)
+	c.droppedTotal.Store(0)
+}
+
+func (c *counter) Inc(now int64, interval time.Duration) uint64 {
+	return c.Add(now, interval, 1)
+}
+
+// Add adds delta to the counter, e.g. the size of the record, and returns the total in the current interval.
+func (c *counter) Add(now int64, interval time.Duration, delta uint64) uint64 {
 	resetAfter := c.resetAt.Load()
 	if resetAfter > now {
-		return c.counter.Add(1)
+		return c.counter.Add(delta)
 	}
 
 	// Reset the counter for next interval
-	c.counter.Store(1)
+	c.counter.Store(delta)
 	newResetAfter := now + interval.Nanoseconds()
 	if !c.resetAt.CompareAndSwap(resetAfter, newResetAfter) {
 		// We raced with another goroutine trying to reset, and it also reset
-		// the counter to 1, so we need to reincrement the counter.
-		return c.counter.Add(1)
+		// the counter to its delta, so we need to re-add the counter.
+		return c.counter.Add(delta)
 	}
 
-	return 1
+	return delta
+}
+
+// Take takes a token from the bucket refilled every interval with the given burst,
+// and reports whether the token is available.
+//
+// It implements the token bucket with the generic cell rate algorithm (GCRA),
+// which reuses resetAt as the theoretical arrival time of the next record.
+func (c *counter) Take(now int64, interval time.Duration, burst int64) bool {
+	tolerance := interval.Nanoseconds() * burst
+	for {
+		arrivalAt := c.resetAt.Load()
+		newArrivalAt := max(arrivalAt, now) + interval.Nanoseconds()
+		if newArrivalAt-now > tolerance {
+			return false
+		}
+		if c.resetAt.CompareAndSwap(arrivalAt, newArrivalAt) {
+			return true
+		}
+	}
 }
@@ -8,6 +8,8 @@ It caps the CPU and I/O load of logging while attempting to preserve a represent
 It logs the first N records with a given level and message each interval.
 If more records with the same level and message are seen during the same interval,
 every Mth message is logged and the rest are dropped.
+Alternatively, it limits records with a token bucket for each level and message if WithTokenBucket is set,
+or with the bytes of records in each interval if WithBytesPerInterval is set.
 
 Keep in mind that the implementation is optimized for speed over absolute precision;
 under load, each interval may be slightly over- or under-sampled.
@@ -20,6 +22,14 @@ import (
 	"time"
 )
 
+// DroppedKey is the key of the attribute which reports the number of records dropped
+// since the last record with the same key is logged if WithReportDropped is enabled.
+const DroppedKey = "dropped"
+
+// SampledDropKey is the key of the attribute which marks the dropped record
+// forwarded as a sample if WithDropSample is set.
+const SampledDropKey = "rate_sampled_drop"
+
 // Handler limits records with give rate, which caps the CPU and I/O load
 // of logging while attempting to preserve a representative subset of your logs.
 //
@@ -30,8 +40,32 @@ type Handler struct {
 	interval time.Duration
 	first    uint64
 	every    uint64
-
-	counts *counters
+	// Dynamic first and every which override the static ones if set.
+	firstFunc func() uint64
+	everyFunc func() uint64
+	// First and every for specific levels which override the global ones if set.
+	levelRates map[slog.Level]levelRate
+
+	keyFunc   func(context.Context, slog.Record) string
+	exempt    slog.Leveler
+	clock     func() time.Time
+	wallClock bool
+
+	reportDropped bool
+	onDrop        func(context.Context, slog.Record)
+	dropSample    uint64
+	staticAttrs   []slog.Attr
+
+	// For bytes per interval.
+	bytes uint64
+
+	// For token bucket.
+	tokenInterval time.Duration
+	burst         int64
+
+	slots  int
+	shared *Counters
+	counts store
 }
 
 // New creates a new Handler with the given Option(s).
@@ -42,12 +76,23 @@ func New(handler slog.Handler, opts ...Option) Handler {
 
 	option := &options{
 		handler: handler,
-		counts:  &counters{},
 		every:   100, //nolint:mnd
 	}
 	for _, opt := range opts {
 		opt(option)
 	}
+	if option.clock == nil {
+		option.clock = time.Now
+	}
+	if option.shared != nil {
+		option.counts = option.shared.store
+	}
+	if option.counts == nil {
+		if option.slots <= 0 {
+			option.slots = countersPerLevel
+		}
+		option.counts = newCounters(option.slots)
+	}
 	if option.interval <= 0 {
 		option.interval = time.Second
 	}
@@ -63,15 +108,128 @@ func (h Handler) Enabled(ctx context.Context, level slog.Level) bool {
 }
 
 func (h Handler) Handle(ctx context.Context, record slog.Record) error {
-	count := h.counts.get(record.Level, record.Message)
-	n := count.Inc(record.Time, h.interval)
-	if n > h.first && (h.every == 0 || (n-h.first)%h.every != 0) {
+	if h.exempt != nil && record.Level >= h.exempt.Level() {
+		return h.forward(ctx, record)
+	}
+
+	key := record.Message
+	if h.keyFunc != nil {
+		key = h.keyFunc(ctx, record)
+	}
+	count := h.counts.get(record.Level, key)
+	var drops *dropCounter
+	if h.reportDropped || h.dropSample > 0 {
+		drops = h.counts.drops(record.Level, key)
+	}
+	if !h.allow(count, record) {
+		if h.reportDropped {
+			drops.dropped.Add(1)
+		}
+		if h.dropSample > 0 && drops.droppedTotal.Add(1)%h.dropSample == 0 {
+			record = record.Clone()
+			record.AddAttrs(slog.Bool(SampledDropKey, true))
+
+			return h.forward(ctx, record)
+		}
+		if h.onDrop != nil {
+			h.onDrop(ctx, record)
+		}
+
 		return nil
 	}
 
+	if h.reportDropped {
+		if dropped := drops.dropped.Swap(0); dropped > 0 {
+			record = record.Clone()
+			record.AddAttrs(slog.Uint64(DroppedKey, dropped))
+		}
+	}
+
+	return h.forward(ctx, record)
+}
+
+func (h Handler) forward(ctx context.Context, record slog.Record) error {
+	if len(h.staticAttrs) > 0 {
+		record = record.Clone()
+		record.AddAttrs(h.staticAttrs...)
+	}
+
 	return h.handler.Handle(ctx, record)
 }
 
+func (h Handler) allow(count *counter, record slog.Record) bool {
+	now := record.Time
+	if now.IsZero() || h.wallClock {
+		now = h.clock()
+	}
+
+	if h.tokenInterval > 0 {
+		return count.Take(now.UnixNano(), h.tokenInterval, h.burst)
+	}
+	if h.bytes > 0 {
+		return count.Add(now.UnixNano(), h.interval, recordSize(record)) <= h.bytes
+	}
+
+	first, every := h.first, h.every
+	if h.firstFunc != nil {
+		first = h.firstFunc()
+	}
+	if h.everyFunc != nil {
+		every = h.everyFunc()
+	}
+	if rate, ok := h.levelRates[record.Level]; ok {
+		every = rate.every
+		if rate.first != 0 {
+			first = rate.first
+		}
+	}
+	n := count.Inc(now.UnixNano(), h.interval)
+
+	return n <= first || (every != 0 && (n-first)%every == 0)
+}
+
+type levelRate struct {
+	first uint64
+	every uint64
+}
+
+// Reset resets all counters, so records are logged as if they are the first in the interval,
+// e.g. after deploying a fix for the flooding records.
+// It's safe to call concurrently with Handle, and it also resets handlers derived from this handler.
+func (h Handler) Reset() {
+	h.counts.reset()
+}
+
+// recordSize estimates the size of the record with the length of message and attributes,
+// which avoids the cost of rendering the record.
+func recordSize(record slog.Record) uint64 {
+	size := uint64(len(record.Message))
+	record.Attrs(func(attr slog.Attr) bool {
+		size += attrSize(attr)
+
+		return true
+	})
+
+	return size
+}
+
+func attrSize(attr slog.Attr) uint64 {
+	size := uint64(len(attr.Key))
+	switch attr.Value.Kind() {
+	case slog.KindString:
+		size += uint64(len(attr.Value.String()))
+	case slog.KindGroup:
+		for _, groupAttr := range attr.Value.Group() {
+			size += attrSize(groupAttr)
+		}
+	default:
+		// It assumes 16 bytes for other kinds, e.g. numbers and time.
+		size += 16 //nolint:mnd
+	}
+
+	return size
+}
+
 func (h Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
 	h.handler = h.handler.WithAttrs(attrs)
 
@@ -79,6 +237,11 @@ func (h Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
 }
 
 func (h Handler) WithGroup(name string) slog.Handler {
+	// Empty group name is a no-op as required by slog.Handler.
+	if name == "" {
+		return h
+	}
+
 	h.handler = h.handler.WithGroup(name)
 
 	return h
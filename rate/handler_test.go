@@ -8,6 +8,8 @@ import (
 	"context"
 	"log/slog"
 	"runtime"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -109,6 +111,509 @@ level=DEBUG msg=msg g.pos=after
 	}
 }
 
+func TestHandler_keyFunc(t *testing.T) {
+	t.Parallel()
+
+	counter := atomic.Int64{}
+	handler := rate.New(
+		countHandler{count: &counter},
+		rate.WithFirst(2),
+		rate.WithEvery(0),
+		rate.WithKeyFunc(func(_ context.Context, record slog.Record) string {
+			var key string
+			record.Attrs(func(attr slog.Attr) bool {
+				if attr.Key == "logger" {
+					key = attr.Value.String()
+
+					return false
+				}
+
+				return true
+			})
+
+			return key
+		}),
+	)
+	logger := slog.New(handler)
+	ctx := context.Background()
+
+	for i := range 10 {
+		logger.InfoContext(ctx, "msg "+strconv.Itoa(i), "logger", "a")
+	}
+	logger.InfoContext(ctx, "msg", "logger", "b")
+
+	assert.Equal(t, 3, int(counter.Load()))
+}
+
+func TestHandler_levelRate(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	handler := rate.New(
+		textHandler(buf),
+		rate.WithFirst(2),
+		rate.WithEvery(0),
+		rate.WithLevelRate(slog.LevelInfo, 5, 5),
+		rate.WithLevelRate(slog.LevelError, 1, 0),
+	)
+	logger := slog.New(handler)
+	ctx := context.Background()
+
+	for range 20 {
+		logger.InfoContext(ctx, "msg")
+		logger.WarnContext(ctx, "msg")
+		logger.ErrorContext(ctx, "msg")
+	}
+
+	assert.Equal(t, 8, strings.Count(buf.String(), "level=INFO"))
+	assert.Equal(t, 2, strings.Count(buf.String(), "level=WARN"))
+	assert.Equal(t, 1, strings.Count(buf.String(), "level=ERROR"))
+}
+
+func TestHandler_levelRateFirstFunc(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	handler := rate.New(
+		textHandler(buf),
+		rate.WithFirst(1),
+		rate.WithFirstFunc(func() uint64 { return 3 }),
+		rate.WithEvery(0),
+		rate.WithLevelRate(slog.LevelWarn, 0, 5),
+		rate.WithLevelRate(slog.LevelError, 0, 0),
+	)
+	logger := slog.New(handler)
+	ctx := context.Background()
+
+	for range 20 {
+		logger.InfoContext(ctx, "msg")
+		logger.WarnContext(ctx, "msg")
+		logger.ErrorContext(ctx, "msg")
+	}
+
+	assert.Equal(t, 3, strings.Count(buf.String(), "level=INFO"))
+	assert.Equal(t, 6, strings.Count(buf.String(), "level=WARN"))
+	assert.Equal(t, 3, strings.Count(buf.String(), "level=ERROR"))
+}
+
+func TestHandler_staticAttrs(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	handler := rate.New(
+		textHandler(buf),
+		rate.WithFirst(1),
+		rate.WithEvery(0),
+		rate.WithExemptLevel(slog.LevelError),
+		rate.WithStaticAttrs(slog.Bool("rate_limited", true)),
+	)
+	logger := slog.New(handler)
+	logger.Info("msg", "a", "A")
+	logger.Info("msg", "a", "A")
+	logger.WithGroup("g").Error("error")
+
+	assert.Equal(t, `level=INFO msg=msg a=A rate_limited=true
+level=ERROR msg=error g.rate_limited=true
+`, buf.String())
+}
+
+func TestHandler_exemptLevel(t *testing.T) {
+	t.Parallel()
+
+	counter := atomic.Int64{}
+	handler := rate.New(
+		countHandler{count: &counter},
+		rate.WithFirst(2),
+		rate.WithEvery(0),
+		rate.WithExemptLevel(slog.LevelError),
+	)
+	logger := slog.New(handler)
+	ctx := context.Background()
+
+	for range 10 {
+		logger.InfoContext(ctx, "msg")
+		logger.ErrorContext(ctx, "msg")
+	}
+
+	assert.Equal(t, 12, int(counter.Load()))
+}
+
+func TestHandler_clock(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(100, 0)
+	counter := atomic.Int64{}
+	handler := rate.New(
+		countHandler{count: &counter},
+		rate.WithFirst(2),
+		rate.WithEvery(0),
+		rate.WithInterval(time.Second),
+		rate.WithClock(func() time.Time { return now }),
+	)
+	ctx := context.Background()
+
+	for range 3 {
+		assert.NoError(t, handler.Handle(ctx, slog.NewRecord(time.Time{}, slog.LevelInfo, "msg", 0)))
+	}
+	assert.Equal(t, 2, int(counter.Load()))
+
+	now = now.Add(time.Second)
+	assert.NoError(t, handler.Handle(ctx, slog.NewRecord(time.Time{}, slog.LevelInfo, "msg", 0)))
+	assert.Equal(t, 3, int(counter.Load()))
+}
+
+func TestHandler_wallClock(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(100, 0)
+	counter := atomic.Int64{}
+	handler := rate.New(
+		countHandler{count: &counter},
+		rate.WithFirst(2),
+		rate.WithEvery(0),
+		rate.WithInterval(time.Second),
+		rate.WithClock(func() time.Time { return now }),
+		rate.WithWallClock(true),
+	)
+	ctx := context.Background()
+	past := time.Unix(0, 0)
+
+	for range 3 {
+		assert.NoError(t, handler.Handle(ctx, slog.NewRecord(past, slog.LevelInfo, "msg", 0)))
+	}
+	assert.Equal(t, 2, int(counter.Load()))
+
+	now = now.Add(time.Second)
+	assert.NoError(t, handler.Handle(ctx, slog.NewRecord(past, slog.LevelInfo, "msg", 0)))
+	assert.Equal(t, 3, int(counter.Load()))
+}
+
+func TestHandler_Reset(t *testing.T) {
+	t.Parallel()
+
+	for _, opt := range []rate.Option{rate.WithCounterSlots(16), rate.WithExactKeys(16)} {
+		counter := atomic.Int64{}
+		handler := rate.New(countHandler{count: &counter}, rate.WithFirst(2), rate.WithEvery(0), opt)
+		logger := slog.New(handler.WithGroup("g"))
+
+		for range 10 {
+			logger.Info("msg")
+		}
+		assert.Equal(t, 2, int(counter.Load()))
+
+		handler.Reset()
+		logger.Info("msg")
+		logger.Info("msg")
+		logger.Info("msg")
+		assert.Equal(t, 4, int(counter.Load()))
+	}
+}
+
+func TestHandler_sharedCounters(t *testing.T) {
+	t.Parallel()
+
+	counter := atomic.Int64{}
+	counters := rate.NewCounters(16)
+	handler1 := rate.New(countHandler{count: &counter}, rate.WithFirst(3), rate.WithEvery(0),
+		rate.WithSharedCounters(counters))
+	handler2 := rate.New(countHandler{count: &counter}, rate.WithFirst(3), rate.WithEvery(0),
+		rate.WithSharedCounters(counters))
+
+	for range 5 {
+		slog.New(handler1).Info("msg")
+		slog.New(handler2).Info("msg")
+	}
+	assert.Equal(t, 3, int(counter.Load()))
+}
+
+func TestHandler_dynamicRate(t *testing.T) {
+	t.Parallel()
+
+	var first, every atomic.Uint64
+	first.Store(2)
+	counter := atomic.Int64{}
+	handler := rate.New(
+		countHandler{count: &counter},
+		rate.WithFirstFunc(first.Load),
+		rate.WithEveryFunc(every.Load),
+	)
+	logger := slog.New(handler)
+	ctx := context.Background()
+
+	for range 5 {
+		logger.InfoContext(ctx, "msg")
+	}
+	assert.Equal(t, 2, int(counter.Load()))
+
+	first.Store(6)
+	for range 5 {
+		logger.InfoContext(ctx, "msg")
+	}
+	assert.Equal(t, 3, int(counter.Load()))
+
+	every.Store(2)
+	for range 4 {
+		logger.InfoContext(ctx, "msg")
+	}
+	assert.Equal(t, 5, int(counter.Load()))
+}
+
+func TestHandler_reportDropped(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	handler := rate.New(
+		textHandler(buf),
+		rate.WithFirst(2),
+		rate.WithEvery(3),
+		rate.WithReportDropped(true),
+	)
+	logger := slog.New(handler)
+	ctx := context.Background()
+
+	for i := range 10 {
+		logger.InfoContext(ctx, "msg", "i", i)
+	}
+
+	assert.Equal(t, `level=INFO msg=msg i=0
+level=INFO msg=msg i=1
+level=INFO msg=msg i=4 dropped=2
+level=INFO msg=msg i=7 dropped=2
+`, buf.String())
+}
+
+func TestHandler_dropSample(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	var dropped []string
+	handler := rate.New(
+		textHandler(buf),
+		rate.WithFirst(2),
+		rate.WithEvery(0),
+		rate.WithDropSample(3),
+		rate.WithOnDrop(func(_ context.Context, record slog.Record) {
+			record.Attrs(func(attr slog.Attr) bool {
+				dropped = append(dropped, attr.Value.String())
+
+				return true
+			})
+		}),
+	)
+	logger := slog.New(handler)
+	ctx := context.Background()
+
+	for i := range 10 {
+		logger.InfoContext(ctx, "msg", "i", i)
+	}
+
+	assert.Equal(t, `level=INFO msg=msg i=0
+level=INFO msg=msg i=1
+level=INFO msg=msg i=4 rate_sampled_drop=true
+level=INFO msg=msg i=7 rate_sampled_drop=true
+`, buf.String())
+	// The sampled records are forwarded, so they are not passed to the drop function.
+	assert.Equal(t, []string{"2", "3", "5", "6", "8", "9"}, dropped)
+}
+
+func TestHandler_tokenBucket(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		burst       int
+		expected    int
+	}{
+		{
+			description: "without burst",
+			expected:    10,
+		},
+		{
+			description: "with burst",
+			burst:       5,
+			expected:    14,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.description, func(t *testing.T) {
+			t.Parallel()
+
+			counter := atomic.Int64{}
+			handler := rate.New(
+				countHandler{count: &counter},
+				rate.WithTokenBucket(10, testcase.burst),
+			)
+			ctx := context.Background()
+
+			// Logs 100 records per second in 1 second, but only 10 records per second are allowed plus burst.
+			start := time.Unix(100, 0)
+			for i := range 100 {
+				record := slog.NewRecord(start.Add(time.Duration(i)*10*time.Millisecond), slog.LevelInfo, "msg", 0)
+				assert.NoError(t, handler.Handle(ctx, record))
+			}
+
+			assert.Equal(t, testcase.expected, int(counter.Load()))
+		})
+	}
+}
+
+func TestHandler_exactKeys(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		opts        []rate.Option
+		expected    int
+	}{
+		{
+			description: "hash keys",
+			expected:    2,
+		},
+		{
+			description: "exact keys",
+			opts:        []rate.Option{rate.WithExactKeys(10)},
+			expected:    4,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.description, func(t *testing.T) {
+			t.Parallel()
+
+			counter := atomic.Int64{}
+			handler := rate.New(
+				countHandler{count: &counter},
+				append(testcase.opts, rate.WithFirst(2), rate.WithEvery(0))...,
+			)
+			logger := slog.New(handler)
+			ctx := context.Background()
+
+			// "msg 318" and "msg 400" share the same counter with hash keys.
+			for range 3 {
+				logger.InfoContext(ctx, "msg 318")
+				logger.InfoContext(ctx, "msg 400")
+			}
+
+			assert.Equal(t, testcase.expected, int(counter.Load()))
+		})
+	}
+}
+
+func TestHandler_onDrop(t *testing.T) {
+	t.Parallel()
+
+	var dropped []string
+	handler := rate.New(
+		countHandler{count: &atomic.Int64{}},
+		rate.WithFirst(2),
+		rate.WithEvery(3),
+		rate.WithOnDrop(func(_ context.Context, record slog.Record) {
+			record.Attrs(func(attr slog.Attr) bool {
+				dropped = append(dropped, attr.Value.String())
+
+				return true
+			})
+		}),
+	)
+	logger := slog.New(handler)
+	ctx := context.Background()
+
+	for i := range 8 {
+		logger.InfoContext(ctx, "msg", "i", i)
+	}
+
+	assert.Equal(t, []string{"2", "3", "5", "6"}, dropped)
+}
+
+func TestHandler_counterSlots(t *testing.T) {
+	t.Parallel()
+
+	counter := atomic.Int64{}
+	handler := rate.New(
+		countHandler{count: &counter},
+		rate.WithFirst(2),
+		rate.WithEvery(0),
+		rate.WithCounterSlots(1),
+	)
+	logger := slog.New(handler)
+	ctx := context.Background()
+
+	for range 3 {
+		logger.InfoContext(ctx, "msg")
+		logger.InfoContext(ctx, "msg2")
+		logger.ErrorContext(ctx, "msg")
+	}
+
+	// All keys share the only counter for each level.
+	assert.Equal(t, 4, int(counter.Load()))
+}
+
+func TestHandler_countersPerLevel(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	handler := rate.New(
+		textHandler(buf),
+		rate.WithFirst(1),
+		rate.WithEvery(0),
+	)
+	logger := slog.New(handler)
+	ctx := context.Background()
+
+	for range 2 {
+		logger.InfoContext(ctx, "msg")
+		logger.WarnContext(ctx, "msg")
+		logger.ErrorContext(ctx, "msg")
+		logger.Log(ctx, slog.LevelError+4, "msg")
+	}
+
+	// Records with the same message but different levels are counted separately,
+	// and levels above error share the counter of error.
+	assert.Equal(t, `level=INFO msg=msg
+level=WARN msg=msg
+level=ERROR msg=msg
+`, buf.String())
+}
+
+func TestHandler_bytesPerInterval(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	handler := rate.New(
+		textHandler(buf),
+		rate.WithBytesPerInterval(100),
+		rate.WithClock(func() time.Time { return time.Unix(100, 0) }),
+	)
+	ctx := context.Background()
+
+	large := strings.Repeat("l", 40)
+	for range 5 {
+		assert.NoError(t, handler.Handle(ctx, slog.NewRecord(time.Time{}, slog.LevelInfo, large, 0)))
+		assert.NoError(t, handler.Handle(ctx, slog.NewRecord(time.Time{}, slog.LevelInfo, "small", 0)))
+	}
+
+	assert.Equal(t, `level=INFO msg=llllllllllllllllllllllllllllllllllllllll
+level=INFO msg=small
+level=INFO msg=llllllllllllllllllllllllllllllllllllllll
+level=INFO msg=small
+level=INFO msg=small
+level=INFO msg=small
+level=INFO msg=small
+`, buf.String())
+}
+
+func TestHandler_emptyGroup(t *testing.T) {
+	t.Parallel()
+
+	buf1, buf2 := &bytes.Buffer{}, &bytes.Buffer{}
+	attrs := []slog.Attr{slog.String("a", "A")}
+	slog.New(rate.New(textHandler(buf1)).WithGroup("").WithAttrs(attrs)).Info("msg", "b", "B")
+	slog.New(rate.New(textHandler(buf2)).WithAttrs(attrs)).Info("msg", "b", "B")
+
+	assert.Equal(t, buf2.String(), buf1.String())
+}
+
 func TestHandler_race(t *testing.T) {
 	t.Parallel()
 
@@ -160,3 +665,15 @@ func (c countHandler) WithAttrs([]slog.Attr) slog.Handler {
 func (c countHandler) WithGroup(string) slog.Handler {
 	return c
 }
+
+func textHandler(buf *bytes.Buffer) slog.Handler {
+	return slog.NewTextHandler(buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if len(groups) == 0 && attr.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+
+			return attr
+		},
+	})
+}
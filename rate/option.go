@@ -3,7 +3,11 @@
 
 package rate
 
-import "time"
+import (
+	"context"
+	"log/slog"
+	"time"
+)
 
 // WithFirst provides N that logs the first N records with a given level and message each interval.
 //
@@ -25,6 +29,37 @@ func WithEvery(every uint64) Option {
 	}
 }
 
+// WithFirstFunc provides a function which returns N for each record,
+// so N could be changed without rebuilding the handler, e.g. raised during incidents.
+// It overrides WithFirst if it is not nil.
+func WithFirstFunc(first func() uint64) Option {
+	return func(options *options) {
+		options.firstFunc = first
+	}
+}
+
+// WithEveryFunc provides a function which returns M for each record,
+// so M could be changed without rebuilding the handler.
+// It overrides WithEvery if it is not nil.
+func WithEveryFunc(every func() uint64) Option {
+	return func(options *options) {
+		options.everyFunc = every
+	}
+}
+
+// WithLevelRate provides N and M for records with the given level, which override
+// WithFirst and WithEvery (and their dynamic versions), e.g. allowing more info records than error records.
+//
+// If the first N is 0, the handler assumes the global N, including the one provided by WithFirstFunc.
+func WithLevelRate(level slog.Level, first, every uint64) Option {
+	return func(options *options) {
+		if options.levelRates == nil {
+			options.levelRates = make(map[slog.Level]levelRate)
+		}
+		options.levelRates[level] = levelRate{first: first, every: every}
+	}
+}
+
 // WithInterval provides the interval for rate limiting.
 //
 // If the interval is <= 0, the handler assumes 1 second.
@@ -34,6 +69,150 @@ func WithInterval(interval time.Duration) Option {
 	}
 }
 
+// WithKeyFunc provides a function to get the key for grouping records,
+// e.g. a message template or the attribute of the logger name, so records with dynamic messages
+// could be limited together.
+//
+// If it is nil, the handler groups records by level and message.
+func WithKeyFunc(keyFunc func(context.Context, slog.Record) string) Option {
+	return func(options *options) {
+		options.keyFunc = keyFunc
+	}
+}
+
+// WithExemptLevel provides the minimum record level that is never limited,
+// e.g. slog.LevelError so errors are always logged while chatty records are limited.
+//
+// By default, records with all levels are limited.
+func WithExemptLevel(level slog.Level) Option {
+	return func(options *options) {
+		options.exempt = level
+	}
+}
+
+// WithClock provides the clock for records without time, e.g. a fake clock for deterministic testing.
+//
+// If it is nil, the handler assumes time.Now.
+func WithClock(clock func() time.Time) Option {
+	return func(options *options) {
+		options.clock = clock
+	}
+}
+
+// WithWallClock bases intervals on the clock provided by WithClock instead of the time of records,
+// so intervals still advance if records have frozen or skewed time, e.g. records replayed from the past.
+//
+// By default, the handler uses the time of records, and only uses the clock for records without time.
+func WithWallClock(wallClock bool) Option {
+	return func(options *options) {
+		options.wallClock = wallClock
+	}
+}
+
+// WithReportDropped adds attribute DroppedKey to the logged record
+// with the number of records dropped since the last logged record with the same key,
+// so the volume of suppressed records is visible.
+func WithReportDropped(reportDropped bool) Option {
+	return func(options *options) {
+		options.reportDropped = reportDropped
+	}
+}
+
+// WithBytesPerInterval limits the estimated bytes of records with a given level and message each interval
+// instead of the first N and every Mth, which bounds the I/O volume if records are large.
+// The size of a record is estimated with the length of its message and attributes.
+//
+// If the bytes is <= 0, the handler uses the first N and every Mth records in each interval.
+func WithBytesPerInterval(bytes int) Option {
+	return func(options *options) {
+		options.bytes = uint64(max(bytes, 0))
+	}
+}
+
+// WithTokenBucket limits records with a token bucket for each key instead of the first N and every Mth
+// in each interval, which gives smoother output without bursts at interval boundaries.
+// The bucket is refilled with the given rate of records per second, and holds up to burst tokens.
+//
+// If the rate is <= 0, the handler uses the first N and every Mth records in each interval.
+// If the burst is <= 0, the handler assumes 1.
+func WithTokenBucket(rate float64, burst int) Option {
+	return func(options *options) {
+		if rate <= 0 {
+			options.tokenInterval = 0
+
+			return
+		}
+
+		options.tokenInterval = time.Duration(float64(time.Second) / rate)
+		options.burst = int64(max(burst, 1))
+	}
+}
+
+// WithCounterSlots provides the number of counters per level which keys are hashed into.
+// Fewer slots use less memory, but keys are more likely to share the same counter due to hash collision.
+// Each slot takes 64 bytes for all levels, and 64 bytes more with WithReportDropped or WithDropSample.
+//
+// If the number is <= 0, the handler assumes 4096.
+func WithCounterSlots(slots int) Option {
+	return func(options *options) {
+		options.slots = slots
+	}
+}
+
+// WithExactKeys keeps counters by exact keys instead of hashes, so different keys never share a counter.
+// It keeps at most maxKeys counters, and evicts the least recently used one once it reaches the maximum.
+//
+// By default, the handler hashes keys into a fixed number of counters for speed,
+// so keys with hash collision might be limited together.
+func WithExactKeys(maxKeys int) Option {
+	return func(options *options) {
+		if maxKeys > 0 {
+			options.counts = newLRUCounters(maxKeys)
+		}
+	}
+}
+
+// WithSharedCounters provides the Counters shared with other handlers,
+// so they jointly respect the rate limit for the same level and key.
+// It takes precedence over WithCounterSlots and WithExactKeys.
+//
+// If it is nil, the handler has its own counters.
+func WithSharedCounters(counters *Counters) Option {
+	return func(options *options) {
+		options.shared = counters
+	}
+}
+
+// WithStaticAttrs adds the given attributes to every record forwarded to the wrapped handler,
+// e.g. `rate_limited=true` for tagging rate-limited streams.
+// Like attributes of the record, they are qualified by groups of the handler.
+func WithStaticAttrs(attrs ...slog.Attr) Option {
+	return func(options *options) {
+		options.staticAttrs = append(options.staticAttrs, attrs...)
+	}
+}
+
+// WithOnDrop provides a function which is called for each dropped record,
+// e.g. for exporting metrics or sampling the dropped content.
+// It is not called for dropped records which are forwarded by WithDropSample.
+// It is called synchronously in Handle, so it should be cheap.
+func WithOnDrop(onDrop func(context.Context, slog.Record)) Option {
+	return func(options *options) {
+		options.onDrop = onDrop
+	}
+}
+
+// WithDropSample forwards every Nth dropped record with the same key, marked with attribute SampledDropKey,
+// so representative content of suppressed records is visible, e.g. while debugging.
+// It is independent of the first N and every Mth records, and the sampled record is still counted as dropped.
+//
+// If N is 0, the handler forwards no dropped records.
+func WithDropSample(every uint64) Option {
+	return func(options *options) {
+		options.dropSample = every
+	}
+}
+
 type (
 	// Option configures the Handler with specific options.
 	Option  func(*options)
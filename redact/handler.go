@@ -0,0 +1,126 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+/*
+Package redact provides a handler for masking sensitive attribute values.
+
+It replaces values of attributes with the given keys or keys matching the given patterns
+with "****", including attributes in nested groups, before they reach the wrapped handler.
+It also masks secrets interpolated into messages if WithMessageMasker is set.
+
+	handler := redact.New(handler,
+		redact.WithKeys("password", "authorization"),
+		redact.WithKeyPatterns(regexp.MustCompile(`(?i)token`)),
+	)
+*/
+package redact
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"slices"
+)
+
+// Masked is the value which replaces the values of sensitive attributes.
+const Masked = "****"
+
+// Handler masks sensitive attribute values.
+//
+// To create a new Handler, call [New].
+type Handler struct {
+	handler  slog.Handler
+	keys     []string
+	patterns []*regexp.Regexp
+	masker   Masker
+
+	messageMasker func(message string) string
+}
+
+// Masker replaces the attribute if it's sensitive, or returns it as is.
+type Masker func(attr slog.Attr) slog.Attr
+
+// New creates a new Handler with the given Option(s).
+func New(handler slog.Handler, opts ...Option) Handler {
+	if handler == nil {
+		panic("cannot create Handler with nil handler")
+	}
+
+	option := &options{handler: handler}
+	for _, opt := range opts {
+		opt(option)
+	}
+
+	return Handler(*option)
+}
+
+func (h Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h Handler) Handle(ctx context.Context, record slog.Record) error {
+	message := record.Message
+	if h.messageMasker != nil {
+		message = h.messageMasker(message)
+	}
+	newRecord := slog.NewRecord(record.Time, record.Level, message, record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		newRecord.AddAttrs(h.redact(attr))
+
+		return true
+	})
+
+	return h.handler.Handle(ctx, newRecord)
+}
+
+func (h Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, 0, len(attrs))
+	for _, attr := range attrs {
+		redacted = append(redacted, h.redact(attr))
+	}
+	h.handler = h.handler.WithAttrs(redacted)
+
+	return h
+}
+
+func (h Handler) WithGroup(name string) slog.Handler {
+	h.handler = h.handler.WithGroup(name)
+
+	return h
+}
+
+func (h Handler) redact(attr slog.Attr) slog.Attr {
+	if h.sensitive(attr.Key) {
+		return slog.String(attr.Key, Masked)
+	}
+
+	attr.Value = attr.Value.Resolve()
+	if attr.Value.Kind() == slog.KindGroup {
+		groupAttrs := attr.Value.Group()
+		redacted := make([]slog.Attr, 0, len(groupAttrs))
+		for _, groupAttr := range groupAttrs {
+			redacted = append(redacted, h.redact(groupAttr))
+		}
+
+		return slog.Attr{Key: attr.Key, Value: slog.GroupValue(redacted...)}
+	}
+
+	if h.masker != nil {
+		return h.masker(attr)
+	}
+
+	return attr
+}
+
+func (h Handler) sensitive(key string) bool {
+	if slices.Contains(h.keys, key) {
+		return true
+	}
+	for _, pattern := range h.patterns {
+		if pattern.MatchString(key) {
+			return true
+		}
+	}
+
+	return false
+}
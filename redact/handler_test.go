@@ -0,0 +1,113 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+package redact_test
+
+import (
+	"bytes"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/nil-go/sloth/internal/assert"
+	"github.com/nil-go/sloth/redact"
+)
+
+func TestNew_panic(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		assert.Equal(t, "cannot create Handler with nil handler", recover().(string))
+	}()
+
+	redact.New(nil)
+	t.Fail()
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		opts        []redact.Option
+		expected    string
+	}{
+		{
+			description: "no options",
+			expected: `level=INFO msg=msg user=admin g.password=secret g.h.authorization="Bearer abc" g.h.token=xyz
+`,
+		},
+		{
+			description: "with keys",
+			opts:        []redact.Option{redact.WithKeys("password", "authorization")},
+			expected: `level=INFO msg=msg user=admin g.password=**** g.h.authorization=**** g.h.token=xyz
+`,
+		},
+		{
+			description: "with key patterns",
+			opts:        []redact.Option{redact.WithKeyPatterns(regexp.MustCompile(`(?i)^(password|token)$`))},
+			expected: `level=INFO msg=msg user=admin g.password=**** g.h.authorization="Bearer abc" g.h.token=****
+`,
+		},
+		{
+			description: "with masker",
+			opts: []redact.Option{redact.WithMasker(func(attr slog.Attr) slog.Attr {
+				if strings.HasPrefix(attr.Value.String(), "Bearer ") {
+					return slog.String(attr.Key, "Bearer "+redact.Masked)
+				}
+
+				return attr
+			})},
+			expected: `level=INFO msg=msg user=admin g.password=secret g.h.authorization="Bearer ****" g.h.token=xyz
+`,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.description, func(t *testing.T) {
+			t.Parallel()
+
+			buf := &bytes.Buffer{}
+			logger := slog.New(redact.New(textHandler(buf), testcase.opts...))
+			logger.With("user", "admin").WithGroup("g").With("password", "secret").
+				Info("msg", slog.Group("h", "authorization", "Bearer abc", "token", tokenValuer("xyz")))
+
+			assert.Equal(t, testcase.expected, buf.String())
+		})
+	}
+}
+
+func TestHandler_messageMasker(t *testing.T) {
+	t.Parallel()
+
+	token := regexp.MustCompile(`Bearer \S+`)
+	buf := &bytes.Buffer{}
+	logger := slog.New(redact.New(textHandler(buf),
+		redact.WithMessageMasker(func(message string) string {
+			return token.ReplaceAllString(message, "Bearer "+redact.Masked)
+		}),
+	))
+	logger.Info("request with Bearer abc failed", "user", "admin")
+
+	assert.Equal(t, `level=INFO msg="request with Bearer **** failed" user=admin
+`, buf.String())
+}
+
+type tokenValuer string
+
+func (t tokenValuer) LogValue() slog.Value {
+	return slog.StringValue(string(t))
+}
+
+func textHandler(buf *bytes.Buffer) slog.Handler {
+	return slog.NewTextHandler(buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if len(groups) == 0 && attr.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+
+			return attr
+		},
+	})
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+package redact
+
+import "regexp"
+
+// WithKeys provides the keys of attributes whose values should be masked.
+// The keys are matched exactly, regardless of the groups of the attributes.
+func WithKeys(keys ...string) Option {
+	return func(options *options) {
+		options.keys = append(options.keys, keys...)
+	}
+}
+
+// WithKeyPatterns provides the patterns of keys of attributes whose values should be masked,
+// e.g. `(?i)token` for any key containing token in any case.
+func WithKeyPatterns(patterns ...*regexp.Regexp) Option {
+	return func(options *options) {
+		options.patterns = append(options.patterns, patterns...)
+	}
+}
+
+// WithMasker provides a Masker for attributes which are not matched by keys or patterns,
+// e.g. masking values which look like credit card numbers.
+// It's not called for groups, but for each attribute in groups.
+func WithMasker(masker Masker) Option {
+	return func(options *options) {
+		options.masker = masker
+	}
+}
+
+// WithMessageMasker provides a function which masks sensitive content in the message of records,
+// e.g. scrubbing tokens interpolated into messages with a regular expression.
+func WithMessageMasker(masker func(message string) string) Option {
+	return func(options *options) {
+		options.messageMasker = masker
+	}
+}
+
+type (
+	// Option configures the Handler with specific options.
+	Option  func(*options)
+	options Handler
+)
@@ -0,0 +1,99 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+/*
+Package route provides a handler for dispatching records to handlers by level.
+
+It dispatches each record to the handler with the highest level threshold
+less than or equal to the level of the record, e.g. records with slog.LevelError and above
+to a paging destination and others to the default destination.
+
+	handler := route.New(map[slog.Level]slog.Handler{slog.LevelError: pagingHandler}, defaultHandler)
+*/
+package route
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"slices"
+)
+
+// Handler dispatches records to handlers by level.
+//
+// To create a new Handler, call [New].
+type Handler struct {
+	// Sorted by level in descending order.
+	routes         []route
+	defaultHandler slog.Handler
+}
+
+type route struct {
+	level   slog.Level
+	handler slog.Handler
+}
+
+// New creates a new Handler that dispatches records with the given routes from level threshold to handler.
+// Records with level lower than all thresholds are dispatched to the default handler.
+//
+// If the default handler is nil, records with level lower than all thresholds are discarded.
+func New(routes map[slog.Level]slog.Handler, defaultHandler slog.Handler) Handler {
+	handler := Handler{routes: make([]route, 0, len(routes)), defaultHandler: defaultHandler}
+	for level, h := range routes {
+		if h == nil {
+			panic("cannot create Handler with nil handler")
+		}
+		handler.routes = append(handler.routes, route{level: level, handler: h})
+	}
+	slices.SortFunc(handler.routes, func(a, b route) int { return cmp.Compare(b.level, a.level) })
+
+	return handler
+}
+
+func (h Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	if handler := h.handler(level); handler != nil {
+		return handler.Enabled(ctx, level)
+	}
+
+	return false
+}
+
+func (h Handler) Handle(ctx context.Context, record slog.Record) error {
+	if handler := h.handler(record.Level); handler != nil {
+		return handler.Handle(ctx, record)
+	}
+
+	return nil
+}
+
+func (h Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(handler slog.Handler) slog.Handler { return handler.WithAttrs(attrs) })
+}
+
+func (h Handler) WithGroup(name string) slog.Handler {
+	return h.with(func(handler slog.Handler) slog.Handler { return handler.WithGroup(name) })
+}
+
+func (h Handler) handler(level slog.Level) slog.Handler {
+	for _, route := range h.routes {
+		if route.level <= level {
+			return route.handler
+		}
+	}
+
+	return h.defaultHandler
+}
+
+func (h Handler) with(fn func(slog.Handler) slog.Handler) Handler {
+	routes := make([]route, len(h.routes))
+	for i, route := range h.routes {
+		route.handler = fn(route.handler)
+		routes[i] = route
+	}
+	h.routes = routes
+	if h.defaultHandler != nil {
+		h.defaultHandler = fn(h.defaultHandler)
+	}
+
+	return h
+}
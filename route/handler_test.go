@@ -0,0 +1,80 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+package route_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/nil-go/sloth/internal/assert"
+	"github.com/nil-go/sloth/route"
+)
+
+func TestNew_panic(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		assert.Equal(t, "cannot create Handler with nil handler", recover().(string))
+	}()
+
+	route.New(map[slog.Level]slog.Handler{slog.LevelError: nil}, nil)
+	t.Fail()
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	errorBuf, warnBuf, defaultBuf := &bytes.Buffer{}, &bytes.Buffer{}, &bytes.Buffer{}
+	handler := route.New(
+		map[slog.Level]slog.Handler{
+			slog.LevelError: textHandler(errorBuf),
+			slog.LevelWarn:  textHandler(warnBuf),
+		},
+		textHandler(defaultBuf),
+	)
+	logger := slog.New(handler).WithGroup("g").With("a", "A")
+	logger.Debug("debug")
+	logger.Info("info")
+	logger.Warn("warn")
+	logger.Error("error")
+	logger.Log(context.Background(), slog.LevelError+4, "fatal")
+
+	assert.Equal(t, `level=ERROR msg=error g.a=A
+level=ERROR+4 msg=fatal g.a=A
+`, errorBuf.String())
+	assert.Equal(t, `level=WARN msg=warn g.a=A
+`, warnBuf.String())
+	assert.Equal(t, `level=INFO msg=info g.a=A
+`, defaultBuf.String())
+}
+
+func TestHandler_noDefault(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	handler := route.New(map[slog.Level]slog.Handler{slog.LevelError: textHandler(buf)}, nil)
+	assert.Equal(t, false, handler.Enabled(context.Background(), slog.LevelInfo))
+	assert.Equal(t, true, handler.Enabled(context.Background(), slog.LevelError))
+
+	logger := slog.New(handler.WithAttrs([]slog.Attr{slog.String("a", "A")}))
+	logger.Info("info")
+	logger.Error("error")
+
+	assert.Equal(t, `level=ERROR msg=error a=A
+`, buf.String())
+}
+
+func textHandler(buf *bytes.Buffer) slog.Handler {
+	return slog.NewTextHandler(buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if len(groups) == 0 && attr.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+
+			return attr
+		},
+	})
+}
@@ -15,6 +15,8 @@ To achieve this, Handler.WithBuffer should be called at the beginning intercepto
 
 	ctx, cancel := h.WithBuffer(ctx)
 	defer cancel()
+
+Alternatively, WithAutoBuffer buffers records for each request automatically without the interceptor.
 */
 package sampling
 
@@ -24,37 +26,72 @@ import (
 	"slices"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 // Handler samples records according to the give sampler.
 //
-// To create a new Handler, call [New].
+// To create a new Handler, call [New] or [NewWithRecordSampler].
 type Handler struct {
-	handler slog.Handler
-	sampler func(ctx context.Context) bool
-
-	level slog.Level
+	handler       slog.Handler
+	sampler       func(ctx context.Context) bool
+	recordSampler func(ctx context.Context, record slog.Record) bool
+
+	level      slog.Level
+	drainLevel slog.Leveler
+	autoBuffer int
+	now        func() time.Time
 }
 
-type contextKey struct{}
+type (
+	contextKey     struct{}
+	forceSampleKey struct{}
+	decisionKey    struct{}
+	decidingKey    struct{}
+)
 
 // New creates a new Handler with the given Option(s).
 func New(handler slog.Handler, sampler func(ctx context.Context) bool, opts ...Option) Handler {
-	if handler == nil {
-		panic("cannot create Handler with nil handler")
+	if sampler == nil {
+		panic("cannot create Handler with nil sampler")
 	}
+
+	return newHandler(handler, &options{sampler: sampler}, opts)
+}
+
+// NewWithRecordSampler creates a new Handler with the given Option(s),
+// which samples records according to both the context and the record, e.g. the attributes of the record.
+//
+// Since Handler.Enabled has no record, it assumes the record might be sampled
+// and leaves the decision to Handler.Handle.
+func NewWithRecordSampler(
+	handler slog.Handler,
+	sampler func(ctx context.Context, record slog.Record) bool,
+	opts ...Option,
+) Handler {
 	if sampler == nil {
 		panic("cannot create Handler with nil sampler")
 	}
 
-	option := &options{
-		handler: handler,
-		sampler: sampler,
-		level:   slog.LevelError,
+	return newHandler(handler, &options{recordSampler: sampler}, opts)
+}
+
+func newHandler(handler slog.Handler, option *options, opts []Option) Handler {
+	if handler == nil {
+		panic("cannot create Handler with nil handler")
 	}
+
+	option.handler = handler
+	option.level = slog.LevelError
 	for _, opt := range opts {
 		opt(option)
 	}
+	if option.drainLevel == nil {
+		option.drainLevel = option.level
+	}
+	if option.now == nil {
+		option.now = time.Now
+	}
 
 	return Handler(*option)
 }
@@ -66,7 +103,8 @@ func (h Handler) Enabled(ctx context.Context, level slog.Level) bool {
 
 	// If the log has not been sampled and there is no buffer in context,
 	// then it only logs while the level is greater than or equal to the handler level.
-	if ctx.Value(contextKey{}) == nil && !h.sampler(ctx) {
+	// The record sampler could not be consulted here since there is no record yet.
+	if !h.buffered(ctx) && h.sampler != nil && !forceSampled(ctx) && !h.decide(ctx) {
 		return level >= h.level
 	}
 
@@ -74,23 +112,75 @@ func (h Handler) Enabled(ctx context.Context, level slog.Level) bool {
 }
 
 func (h Handler) Handle(ctx context.Context, record slog.Record) error {
-	if h.sampler(ctx) {
+	if h.sampled(ctx, record) {
 		return h.handler.Handle(ctx, record)
 	}
 
 	// If there is buffer in context and the log has not been sampled,
 	// then the record is handled by the buffer.
-	if b, ok := ctx.Value(contextKey{}).(*buffer); ok {
+	b, ok := ctx.Value(contextKey{}).(*buffer)
+	if !ok && h.autoBuffer > 0 {
+		b, ok = autoBufferRegistry.get(ctx, h.autoBuffer, record.Level < h.level)
+	}
+	if ok {
 		if record.Level < h.level {
-			return b.buffer(ctx, h.handler, record)
+			return b.buffer(ctx, h.handler, record, h.now)
 		}
 
-		b.drain()
+		if record.Level >= h.drainLevel.Level() {
+			b.drain(h.now)
+		}
+	} else if record.Level < h.level && h.recordSampler != nil {
+		// Enabled could not filter unsampled records with lower level for the record sampler.
+		return nil
 	}
 
 	return h.handler.Handle(ctx, record)
 }
 
+func (h Handler) buffered(ctx context.Context) bool {
+	return ctx.Value(contextKey{}) != nil || h.autoBuffer > 0 && ctx.Done() != nil
+}
+
+func (h Handler) sampled(ctx context.Context, record slog.Record) bool {
+	if forceSampled(ctx) {
+		return true
+	}
+	if h.recordSampler != nil {
+		return h.recordSampler(ctx, record)
+	}
+
+	return h.decide(ctx)
+}
+
+// decide returns the decision cached by WithCachedDecision if present,
+// otherwise it consults the sampler.
+func (h Handler) decide(ctx context.Context) bool {
+	if sampled, ok := ctx.Value(decisionKey{}).(bool); ok {
+		return sampled
+	}
+
+	return h.sampler(ctx)
+}
+
+// WithCachedDecision evaluates the sampler once and caches the decision in the returned context,
+// so handlers sampling with the same context, e.g. stacked handlers or both Enabled and Handle,
+// get the same decision without calling the sampler again, even if the sampler is probabilistic.
+// It usually should be called at the beginning interceptor of the gRPC/HTTP request.
+//
+// It returns the context as is if the handler is created by NewWithRecordSampler,
+// since the decision depends on each record.
+func (h Handler) WithCachedDecision(ctx context.Context) context.Context {
+	if h.sampler == nil {
+		return ctx
+	}
+
+	// Mark the context, so samplers like RateLimited know the decision is cached for the request.
+	sampled := h.sampler(context.WithValue(ctx, decidingKey{}, true))
+
+	return context.WithValue(ctx, decisionKey{}, sampled)
+}
+
 func (h Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
 	h.handler = h.handler.WithAttrs(attrs)
 
@@ -98,11 +188,50 @@ func (h Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
 }
 
 func (h Handler) WithGroup(name string) slog.Handler {
+	// Empty group name is a no-op as required by slog.Handler.
+	if name == "" {
+		return h
+	}
+
 	h.handler = h.handler.WithGroup(name)
 
 	return h
 }
 
+// ForceSample marks the request associated with the given context as sampled regardless of the sampler,
+// e.g. the request is flagged by a debug header.
+func ForceSample(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forceSampleKey{}, true)
+}
+
+func forceSampled(ctx context.Context) bool {
+	sampled, _ := ctx.Value(forceSampleKey{}).(bool)
+
+	return sampled
+}
+
+// Begin enables log buffering for the request associated with the given context like WithBuffer,
+// and returns a function which should be deferred to end the request:
+//
+//	ctx, end := sampling.Begin(ctx)
+//	defer end()
+//
+// If the request panics, the end function drains the buffer before releasing it and re-panics,
+// so records logged before the panic are not lost.
+func Begin(ctx context.Context, opts ...BufferOption) (context.Context, func()) {
+	ctx, cancel := WithBuffer(ctx, opts...)
+
+	return ctx, func() {
+		defer cancel()
+
+		// The function has to be deferred directly, so recover could stop the panic.
+		if r := recover(); r != nil {
+			Flush(ctx)
+			panic(r)
+		}
+	}
+}
+
 // WithBuffer enables log buffering for the request associated with the given context.
 // It usually should be called at the beginning interceptor of the gRPC/HTTP request.
 //
@@ -111,18 +240,121 @@ func (h Handler) WithGroup(name string) slog.Handler {
 //
 //	ctx, cancel := h.WithBuffer(ctx)
 //	defer cancel()
-func WithBuffer(ctx context.Context) (context.Context, func()) {
-	buf := bufferPool.Get().(*buffer) //nolint:forcetypeassert,errcheck
+//
+// If there is already a buffer in the context, e.g. both outer and inner interceptors call it,
+// it reuses the existing buffer and ignores the given BufferOption(s).
+// The returned cancel is a no-op in this case, and the buffer is released by the outermost cancel.
+func WithBuffer(ctx context.Context, opts ...BufferOption) (context.Context, func()) {
+	if _, ok := ctx.Value(contextKey{}).(*buffer); ok {
+		return ctx, func() {}
+	}
+
+	option := &bufferOptions{size: defaultBufferSize}
+	for _, opt := range opts {
+		opt(option)
+	}
+	if option.size <= 0 {
+		option.size = defaultBufferSize
+	}
+
+	buf := newBuffer(*option)
 	ctx = context.WithValue(ctx, contextKey{}, buf)
 
 	return ctx, buf.reset
 }
 
+func newBuffer(option bufferOptions) *buffer {
+	buf := bufferPool.Get().(*buffer) //nolint:forcetypeassert,errcheck
+	buf.bufferOptions = option
+	if cap(buf.entries) != buf.size {
+		buf.entries = make(chan entry, buf.size)
+	}
+
+	return buf
+}
+
+// autoBuffers keeps buffers created by WithAutoBuffer for requests.
+// Since the context could not be changed by Handle, the buffer is keyed by the done channel of the context,
+// which is shared by contexts derived with values, e.g. the span in the context.
+// It's shared by all handlers like the buffer created by WithBuffer, so Flush and BufferStats could find it.
+type autoBuffers struct {
+	mu      sync.Mutex
+	buffers map[<-chan struct{}]*buffer
+}
+
+var autoBufferRegistry = &autoBuffers{buffers: make(map[<-chan struct{}]*buffer)} //nolint:gochecknoglobals
+
+func (a *autoBuffers) get(ctx context.Context, size int, create bool) (*buffer, bool) {
+	done := ctx.Done()
+	if done == nil {
+		// The context is never canceled, so the buffer could not be released.
+		return nil, false
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if buf, ok := a.buffers[done]; ok {
+		return buf, true
+	}
+	if !create {
+		return nil, false
+	}
+
+	// The buffer re-arms after each drain, since the context might be a long-lived parent
+	// shared by requests, e.g. the server context, and an error should not turn off sampling for all of them.
+	buf := newBuffer(bufferOptions{size: size, maxBuffered: size, drainOnce: true})
+	a.buffers[done] = buf
+	context.AfterFunc(ctx, func() {
+		a.mu.Lock()
+		delete(a.buffers, done)
+		a.mu.Unlock()
+		buf.reset()
+	})
+
+	return buf, true
+}
+
+// lookupBuffer returns the buffer in the context, or the buffer created by WithAutoBuffer for the context.
+func lookupBuffer(ctx context.Context) (*buffer, bool) {
+	if b, ok := ctx.Value(contextKey{}).(*buffer); ok {
+		return b, true
+	}
+
+	return autoBufferRegistry.get(ctx, 0, false)
+}
+
+const defaultBufferSize = 8
+
+// Flush drains records buffered for the request associated with the given context,
+// e.g. the request is slow so its records are needed for debugging even without error.
+// The records logged after it are not buffered anymore for the request,
+// unless the buffer re-arms after each drain, e.g. WithDrainOnce or the buffer created by WithAutoBuffer.
+// Records out of the buffer window are evicted by the clock of the handler, e.g. WithNow.
+//
+// It is a no-op if there is no buffer for the context.
+func Flush(ctx context.Context) {
+	if b, ok := lookupBuffer(ctx); ok {
+		b.drain(nil)
+	}
+}
+
 type (
 	buffer struct {
+		bufferOptions
+
+		// mu guards entries and overflow since records could be buffered
+		// from goroutines spawned by the request with the same context.
+		mu       sync.Mutex
 		entries  chan entry
 		overflow []entry
 		drained  atomic.Bool
+		dropped  int
+		// now is the clock of the handler which buffers records, which is used by Flush.
+		now func() time.Time
+
+		highWater  int
+		overflowed bool
 	}
 
 	entry struct {
@@ -132,70 +364,203 @@ type (
 	}
 )
 
-func (b *buffer) buffer(ctx context.Context, handler slog.Handler, record slog.Record) error {
+// BufferStats returns the statistics of the buffer associated with the given context,
+// which could be used for tuning the buffer size.
+// The depth is the number of records currently in the buffer,
+// the highWater is the maximum depth the buffer has reached,
+// and overflowed reports whether records have exceeded the buffer size.
+//
+// It is safe to call while the request is running, and returns zeros if there is no buffer for the context.
+func BufferStats(ctx context.Context) (int, int, bool) {
+	b, ok := lookupBuffer(ctx)
+	if !ok {
+		return 0, 0, false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.entries) + len(b.overflow), b.highWater, b.overflowed
+}
+
+func (b *buffer) buffer(ctx context.Context, handler slog.Handler, record slog.Record, now func() time.Time) error {
+	if drained := b.drained.Load(); drained {
+		return handler.Handle(ctx, record)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Check again since the buffer might be drained while waiting for the lock.
 	if drained := b.drained.Load(); drained {
 		return handler.Handle(ctx, record)
 	}
+	b.now = now
+
+	// If the buffer reaches the maximum, then drop the oldest record.
+	if b.maxBuffered > 0 && len(b.entries)+len(b.overflow) >= b.maxBuffered {
+		if len(b.overflow) > 0 {
+			b.overflow = slices.Delete(b.overflow, 0, 1)
+			b.dropped++
+		} else {
+			select {
+			case <-b.entries:
+				b.dropped++
+			default:
+			}
+		}
+	}
 
 	for {
 		select {
 		case b.entries <- entry{handler: handler, ctx: ctx, record: record}:
+			b.highWater = max(b.highWater, len(b.entries)+len(b.overflow))
+
 			return nil
 		default:
 			// If the buffer is full, then move it to overflow.
 			if len(b.overflow) == cap(b.overflow) {
-				b.overflow = slices.Grow(b.overflow, len(b.entries))
+				// Evict expired records before growing the overflow.
+				b.evict(now())
+			}
+			if len(b.overflow) == cap(b.overflow) {
+				chunk := b.overflowChunk
+				if chunk <= 0 {
+					chunk = len(b.entries)
+				}
+				b.overflow = slices.Grow(b.overflow, chunk)
 			}
 			b.overflow = append(b.overflow, <-b.entries)
+			b.overflowed = true
 		}
 	}
 }
 
-func (b *buffer) drain() {
+// drain replays buffered records with the given clock,
+// or the clock of the handler which buffers records if it is nil.
+func (b *buffer) drain(now func() time.Time) {
+	b.mu.Lock()
 	if drained := b.drained.Swap(true); drained {
+		b.mu.Unlock()
+
 		return
 	}
-
-	for _, e := range b.overflow {
-		// Here ignores the error for best effort.
-		_ = e.handler.Handle(e.ctx, e.record)
+	if now == nil {
+		now = b.now
+	}
+	if now == nil {
+		now = time.Now
 	}
-	clear(b.overflow)
-	b.overflow = b.overflow[:0]
 
+	// Merge entries into overflow, and copy them out so records are replayed without holding the lock.
+loop:
 	for {
 		select {
 		case e := <-b.entries:
-			// Here ignores the error for best effort.
-			_ = e.handler.Handle(e.ctx, e.record)
+			b.overflow = append(b.overflow, e)
 		default:
-			return
+			break loop
+		}
+	}
+	entries := make([]entry, 0, len(b.overflow))
+	drainedAt := now()
+	for _, e := range b.overflow {
+		if b.expired(e, drainedAt) {
+			b.dropped++
+
+			continue
+		}
+		entries = append(entries, e)
+	}
+	clear(b.overflow)
+	b.overflow = b.overflow[:0]
+
+	if b.drainOnce {
+		// Re-arm the buffer so records are buffered again until the next drain.
+		b.drained.Store(false)
+	}
+	b.mu.Unlock()
+
+	sortByTime(entries)
+	for _, e := range entries {
+		// Here ignores the error for best effort.
+		_ = e.handler.Handle(e.ctx, e.record)
+	}
+}
+
+// sortByTime sorts entries by time, so records are replayed in chronological order
+// even if they are buffered out of order by concurrent goroutines.
+// Records without time could not be compared, so they keep their positions in arrival order.
+func sortByTime(entries []entry) {
+	var timed []int
+	for i, e := range entries {
+		if !e.record.Time.IsZero() {
+			timed = append(timed, i)
 		}
 	}
+
+	sorted := make([]entry, 0, len(timed))
+	for _, i := range timed {
+		sorted = append(sorted, entries[i])
+	}
+	slices.SortStableFunc(sorted, func(a, b entry) int { return a.record.Time.Compare(b.record.Time) })
+	for k, i := range timed {
+		entries[i] = sorted[k]
+	}
+}
+
+func (b *buffer) evict(now time.Time) {
+	if b.window <= 0 {
+		return
+	}
+
+	count := len(b.overflow)
+	b.overflow = slices.DeleteFunc(b.overflow, func(e entry) bool { return b.expired(e, now) })
+	b.dropped += count - len(b.overflow)
+}
+
+// expired reports whether the record is out of the buffer window.
+// The record without time is never expired.
+func (b *buffer) expired(e entry, now time.Time) bool {
+	return b.window > 0 && !e.record.Time.IsZero() && e.record.Time.Before(now.Add(-b.window))
 }
 
 func (b *buffer) reset() {
+	b.mu.Lock()
+	discarded := b.dropped
 	if drained := b.drained.Swap(false); !drained {
 		// Discard the buffer.
+		discarded += len(b.overflow)
 	loop:
 		for {
 			select {
 			case <-b.entries:
+				discarded++
 			default:
 				break loop
 			}
 		}
 	}
+	onDiscard := b.onDiscard
 	clear(b.overflow)
 	b.overflow = b.overflow[:0]
-
+	b.dropped = 0
+	b.highWater = 0
+	b.overflowed = false
+	b.now = nil
+	b.bufferOptions = bufferOptions{}
+	b.mu.Unlock()
+
+	if discarded > 0 && onDiscard != nil {
+		onDiscard(discarded)
+	}
 	bufferPool.Put(b)
 }
 
 var bufferPool = sync.Pool{ //nolint:gochecknoglobals
 	New: func() interface{} {
 		return &buffer{
-			entries: make(chan entry, 8), //nolint:mnd
+			entries: make(chan entry, defaultBufferSize),
 		}
 	},
 }
@@ -6,8 +6,14 @@ package sampling_test
 import (
 	"bytes"
 	"context"
+	"io"
 	"log/slog"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/nil-go/sloth/internal/assert"
 	"github.com/nil-go/sloth/sampling"
@@ -128,6 +134,96 @@ level=INFO msg=info3
 	}
 }
 
+func TestForceSample(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	handler := sampling.New(
+		slog.NewTextHandler(buf, &slog.HandlerOptions{
+			Level: slog.LevelDebug,
+			ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+				if len(groups) == 0 && attr.Key == slog.TimeKey {
+					return slog.Attr{}
+				}
+
+				return attr
+			},
+		}),
+		func(context.Context) bool { return false },
+	)
+	logger := slog.New(handler)
+
+	ctx := sampling.ForceSample(context.Background())
+	logger.DebugContext(ctx, "debug")
+	logger.InfoContext(ctx, "info")
+	logger.InfoContext(context.Background(), "unsampled")
+
+	assert.Equal(t, `level=DEBUG msg=debug
+level=INFO msg=info
+`, buf.String())
+}
+
+func TestHandler_recordSampler(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	handler := sampling.NewWithRecordSampler(
+		textHandler(buf),
+		func(_ context.Context, record slog.Record) bool {
+			var sampled bool
+			record.Attrs(func(attr slog.Attr) bool {
+				if attr.Key == "tenant" && attr.Value.String() == "a" {
+					sampled = true
+
+					return false
+				}
+
+				return true
+			})
+
+			return sampled
+		},
+	)
+	logger := slog.New(handler)
+	ctx := context.Background()
+
+	logger.InfoContext(ctx, "info", "tenant", "a")
+	logger.InfoContext(ctx, "info", "tenant", "b")
+	logger.ErrorContext(ctx, "error", "tenant", "b")
+
+	assert.Equal(t, `level=INFO msg=info tenant=a
+level=ERROR msg=error tenant=b
+`, buf.String())
+}
+
+func TestHandler_WithCachedDecision(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	// The sampler alternates the decision on each call like a probabilistic sampler.
+	var calls int
+	handler := sampling.New(
+		textHandler(buf),
+		func(context.Context) bool {
+			calls++
+
+			return calls%2 == 1
+		},
+	)
+	logger := slog.New(handler)
+
+	ctx := handler.WithCachedDecision(context.Background())
+	logger.InfoContext(ctx, "info 1")
+	logger.InfoContext(ctx, "info 2")
+	logger.InfoContext(ctx, "info 3")
+
+	assert.Equal(t, `level=INFO msg="info 1"
+level=INFO msg="info 2"
+level=INFO msg="info 3"
+`, buf.String())
+	assert.Equal(t, 1, calls)
+}
+
 func TestHandler_overflow(t *testing.T) {
 	buf := &bytes.Buffer{}
 	handler := sampling.New(
@@ -173,3 +269,554 @@ level=INFO msg=info10
 `
 	assert.Equal(t, expected, buf.String())
 }
+
+func TestHandler_bufferSize(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	handler := sampling.New(textHandler(buf), func(context.Context) bool { return false })
+	logger := slog.New(handler)
+
+	ctx, put := sampling.WithBuffer(context.Background(), sampling.WithBufferSize(32))
+	defer put()
+
+	var expected strings.Builder
+	for i := range 32 {
+		logger.InfoContext(ctx, "info", "i", i)
+		expected.WriteString("level=INFO msg=info i=" + strconv.Itoa(i) + "\n")
+	}
+	assert.Equal(t, "", buf.String())
+
+	logger.ErrorContext(ctx, "error")
+	expected.WriteString("level=ERROR msg=error\n")
+	assert.Equal(t, expected.String(), buf.String())
+}
+
+func TestHandler_drainLevel(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	handler := sampling.New(textHandler(buf), func(context.Context) bool { return false },
+		sampling.WithLevel(slog.LevelWarn),
+		sampling.WithDrainLevel(slog.LevelError),
+	)
+	logger := slog.New(handler)
+
+	ctx, put := sampling.WithBuffer(context.Background())
+	defer put()
+
+	logger.InfoContext(ctx, "info")
+	logger.WarnContext(ctx, "warn")
+	assert.Equal(t, "level=WARN msg=warn\n", buf.String())
+
+	logger.ErrorContext(ctx, "error")
+	assert.Equal(t, `level=WARN msg=warn
+level=INFO msg=info
+level=ERROR msg=error
+`, buf.String())
+}
+
+func TestHandler_drainOnce(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	handler := sampling.New(textHandler(buf), func(context.Context) bool { return false })
+	logger := slog.New(handler)
+
+	ctx, put := sampling.WithBuffer(context.Background(), sampling.WithDrainOnce(true))
+	defer put()
+
+	logger.InfoContext(ctx, "info 1")
+	logger.ErrorContext(ctx, "error 1")
+	logger.InfoContext(ctx, "info 2")
+	assert.Equal(t, `level=INFO msg="info 1"
+level=ERROR msg="error 1"
+`, buf.String())
+
+	logger.ErrorContext(ctx, "error 2")
+	logger.InfoContext(ctx, "info 3")
+	assert.Equal(t, `level=INFO msg="info 1"
+level=ERROR msg="error 1"
+level=INFO msg="info 2"
+level=ERROR msg="error 2"
+`, buf.String())
+}
+
+func TestHandler_maxBuffered(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	handler := sampling.New(textHandler(buf), func(context.Context) bool { return false })
+	logger := slog.New(handler)
+
+	var discarded int
+	ctx, put := sampling.WithBuffer(context.Background(),
+		sampling.WithMaxBuffered(10),
+		sampling.WithOnDiscard(func(n int) { discarded = n }),
+	)
+
+	for i := range 10000 {
+		logger.InfoContext(ctx, "info", "i", i)
+	}
+	logger.ErrorContext(ctx, "error")
+	put()
+
+	var expected strings.Builder
+	for i := 9990; i < 10000; i++ {
+		expected.WriteString("level=INFO msg=info i=" + strconv.Itoa(i) + "\n")
+	}
+	expected.WriteString("level=ERROR msg=error\n")
+	assert.Equal(t, expected.String(), buf.String())
+	assert.Equal(t, 9990, discarded)
+}
+
+func TestHandler_bufferWindow(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	handler := sampling.New(textHandler(buf), func(context.Context) bool { return false })
+
+	var discarded int
+	ctx, put := sampling.WithBuffer(context.Background(),
+		sampling.WithBufferWindow(time.Minute),
+		sampling.WithOnDiscard(func(n int) { discarded = n }),
+	)
+
+	now := time.Now()
+	assert.NoError(t, handler.Handle(ctx, slog.NewRecord(now.Add(-time.Hour), slog.LevelInfo, "hour ago", 0)))
+	assert.NoError(t, handler.Handle(ctx, slog.NewRecord(now.Add(-2*time.Minute), slog.LevelInfo, "2 minutes ago", 0)))
+	assert.NoError(t, handler.Handle(ctx, slog.NewRecord(time.Time{}, slog.LevelInfo, "no time", 0)))
+	assert.NoError(t, handler.Handle(ctx, slog.NewRecord(now.Add(-time.Second), slog.LevelInfo, "second ago", 0)))
+	assert.NoError(t, handler.Handle(ctx, slog.NewRecord(now, slog.LevelError, "error", 0)))
+	put()
+
+	assert.Equal(t, `level=INFO msg="no time"
+level=INFO msg="second ago"
+level=ERROR msg=error
+`, buf.String())
+	assert.Equal(t, 2, discarded)
+}
+
+func TestHandler_now(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	buf := &bytes.Buffer{}
+	handler := sampling.New(textHandler(buf), func(context.Context) bool { return false },
+		sampling.WithNow(func() time.Time { return now }),
+	)
+	ctx, put := sampling.WithBuffer(context.Background(), sampling.WithBufferWindow(time.Minute))
+	defer put()
+
+	assert.NoError(t, handler.Handle(ctx, slog.NewRecord(start, slog.LevelInfo, "first", 0)))
+	assert.NoError(t, handler.Handle(ctx, slog.NewRecord(start.Add(30*time.Second), slog.LevelInfo, "second", 0)))
+	now = start.Add(time.Minute + time.Second)
+	assert.NoError(t, handler.Handle(ctx, slog.NewRecord(start, slog.LevelError, "error", 0)))
+
+	assert.Equal(t, `level=INFO msg=second
+level=ERROR msg=error
+`, buf.String())
+}
+
+func TestFlush_now(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	buf := &bytes.Buffer{}
+	handler := sampling.New(textHandler(buf), func(context.Context) bool { return false },
+		sampling.WithNow(func() time.Time { return now }),
+	)
+	ctx, put := sampling.WithBuffer(context.Background(), sampling.WithBufferWindow(time.Minute))
+	defer put()
+
+	assert.NoError(t, handler.Handle(ctx, slog.NewRecord(start, slog.LevelInfo, "first", 0)))
+	assert.NoError(t, handler.Handle(ctx, slog.NewRecord(start.Add(30*time.Second), slog.LevelInfo, "second", 0)))
+	// Flush uses the clock of the handler rather than the wall clock, which is far after the window.
+	now = start.Add(time.Minute + time.Second)
+	sampling.Flush(ctx)
+
+	assert.Equal(t, "level=INFO msg=second\n", buf.String())
+}
+
+func TestBegin(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	handler := sampling.New(textHandler(buf), func(context.Context) bool { return false })
+	logger := slog.New(handler)
+
+	var recovered any
+	func() {
+		defer func() { recovered = recover() }()
+
+		ctx, end := sampling.Begin(context.Background())
+		defer end()
+
+		logger.InfoContext(ctx, "before panic")
+		panic("boom")
+	}()
+
+	assert.Equal(t, "boom", recovered)
+	assert.Equal(t, "level=INFO msg=\"before panic\"\n", buf.String())
+
+	buf.Reset()
+	ctx, end := sampling.Begin(context.Background())
+	logger.InfoContext(ctx, "no panic")
+	end()
+	assert.Equal(t, "", buf.String())
+}
+
+func TestHandler_overflowChunk(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	handler := sampling.New(textHandler(buf), func(context.Context) bool { return false })
+	ctx, put := sampling.WithBuffer(context.Background(), sampling.WithBufferSize(2), sampling.WithOverflowChunk(3))
+	defer put()
+
+	logger := slog.New(handler)
+	var expected strings.Builder
+	for i := range 20 {
+		logger.InfoContext(ctx, "info", "i", i)
+		expected.WriteString("level=INFO msg=info i=" + strconv.Itoa(i) + "\n")
+	}
+	logger.ErrorContext(ctx, "error")
+	expected.WriteString("level=ERROR msg=error\n")
+
+	assert.Equal(t, expected.String(), buf.String())
+}
+
+func BenchmarkHandler_buffer(b *testing.B) {
+	handler := sampling.New(slog.NewTextHandler(io.Discard, nil), func(context.Context) bool { return false })
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, "info", 0)
+
+	for _, chunk := range []int{0, 64} {
+		b.Run("chunk "+strconv.Itoa(chunk), func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				ctx, put := sampling.WithBuffer(context.Background(), sampling.WithOverflowChunk(chunk))
+				for range 100 {
+					_ = handler.Handle(ctx, record)
+				}
+				put()
+			}
+		})
+	}
+}
+
+func TestHandler_drainOrder(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	handler := sampling.New(slog.NewTextHandler(buf, nil), func(context.Context) bool { return false })
+	ctx, put := sampling.WithBuffer(context.Background(), sampling.WithBufferSize(2))
+	defer put()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, second := range []int{3, 1, 4, 2, 0} {
+		record := slog.NewRecord(start.Add(time.Duration(second)*time.Second), slog.LevelInfo, "info", 0)
+		assert.NoError(t, handler.Handle(ctx, record))
+	}
+	assert.NoError(t, handler.Handle(ctx, slog.NewRecord(start.Add(5*time.Second), slog.LevelError, "error", 0)))
+
+	assert.Equal(t, `time=2024-01-01T00:00:00.000Z level=INFO msg=info
+time=2024-01-01T00:00:01.000Z level=INFO msg=info
+time=2024-01-01T00:00:02.000Z level=INFO msg=info
+time=2024-01-01T00:00:03.000Z level=INFO msg=info
+time=2024-01-01T00:00:04.000Z level=INFO msg=info
+time=2024-01-01T00:00:05.000Z level=ERROR msg=error
+`, buf.String())
+}
+
+func TestHandler_drainOrderWithoutTime(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	handler := sampling.New(slog.NewTextHandler(buf, nil), func(context.Context) bool { return false })
+	ctx, put := sampling.WithBuffer(context.Background())
+	defer put()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, record := range []slog.Record{
+		slog.NewRecord(start.Add(3*time.Second), slog.LevelInfo, "3", 0),
+		slog.NewRecord(time.Time{}, slog.LevelInfo, "a", 0),
+		slog.NewRecord(start.Add(time.Second), slog.LevelInfo, "1", 0),
+		slog.NewRecord(time.Time{}, slog.LevelInfo, "b", 0),
+		slog.NewRecord(start.Add(2*time.Second), slog.LevelInfo, "2", 0),
+	} {
+		assert.NoError(t, handler.Handle(ctx, record))
+	}
+	sampling.Flush(ctx)
+
+	// Records without time keep their positions in arrival order.
+	assert.Equal(t, `time=2024-01-01T00:00:01.000Z level=INFO msg=1
+level=INFO msg=a
+time=2024-01-01T00:00:02.000Z level=INFO msg=2
+level=INFO msg=b
+time=2024-01-01T00:00:03.000Z level=INFO msg=3
+`, buf.String())
+}
+
+func TestHandler_drainWithoutLock(t *testing.T) {
+	t.Parallel()
+
+	var depths []int
+	handler := sampling.New(
+		statsHandler{Handler: slog.NewTextHandler(io.Discard, nil), depths: &depths},
+		func(context.Context) bool { return false },
+	)
+	logger := slog.New(handler)
+	ctx, put := sampling.WithBuffer(context.Background())
+	defer put()
+
+	logger.InfoContext(ctx, "info")
+	logger.InfoContext(ctx, "info2")
+	// The inner handler would deadlock if records were forwarded while holding the lock of the buffer.
+	logger.ErrorContext(ctx, "error")
+
+	assert.Equal(t, []int{0, 0, 0}, depths)
+}
+
+// statsHandler records the depth of the buffer while handling each record.
+type statsHandler struct {
+	slog.Handler
+
+	depths *[]int
+}
+
+func (h statsHandler) Handle(ctx context.Context, record slog.Record) error {
+	depth, _, _ := sampling.BufferStats(ctx)
+	*h.depths = append(*h.depths, depth)
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func TestHandler_autoBuffer(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	handler := sampling.New(textHandler(buf), func(context.Context) bool { return false }, sampling.WithAutoBuffer(2))
+	logger := slog.New(handler)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	logger.InfoContext(ctx, "info 1")
+	// The buffer is shared by contexts derived with values.
+	logger.InfoContext(context.WithValue(ctx, ctxKey{}, "value"), "info 2")
+	logger.InfoContext(ctx, "info 3")
+	logger.ErrorContext(ctx, "error")
+	// The record in the context without cancel is not buffered.
+	logger.InfoContext(context.Background(), "info 4")
+	logger.ErrorContext(context.Background(), "error")
+
+	assert.Equal(t, `level=INFO msg="info 2"
+level=INFO msg="info 3"
+level=ERROR msg=error
+level=ERROR msg=error
+`, buf.String())
+}
+
+func TestHandler_autoBufferSharedParent(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	handler := sampling.New(textHandler(buf), func(context.Context) bool { return false }, sampling.WithAutoBuffer(2))
+	logger := slog.New(handler)
+
+	// Requests share the long-lived cancelable parent, e.g. the server context.
+	parent, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The first request drains the buffer with the error.
+	logger.InfoContext(parent, "request 1")
+	logger.ErrorContext(parent, "error 1")
+	// The buffer re-arms, so the second request is still sampled.
+	logger.InfoContext(parent, "request 2")
+	assert.Equal(t, `level=INFO msg="request 1"
+level=ERROR msg="error 1"
+`, buf.String())
+
+	depth, _, _ := sampling.BufferStats(parent)
+	assert.Equal(t, 1, depth)
+	sampling.Flush(parent)
+	assert.Equal(t, `level=INFO msg="request 1"
+level=ERROR msg="error 1"
+level=INFO msg="request 2"
+`, buf.String())
+}
+
+type ctxKey struct{}
+
+func TestWithBuffer_nested(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	handler := sampling.New(textHandler(buf), func(context.Context) bool { return false })
+	logger := slog.New(handler)
+
+	ctx, put := sampling.WithBuffer(context.Background())
+	defer put()
+	innerCtx, innerPut := sampling.WithBuffer(ctx)
+	logger.InfoContext(innerCtx, "info")
+	innerPut()
+
+	logger.InfoContext(ctx, "info2")
+	logger.ErrorContext(ctx, "error")
+
+	assert.Equal(t, `level=INFO msg=info
+level=INFO msg=info2
+level=ERROR msg=error
+`, buf.String())
+}
+
+func TestBufferStats(t *testing.T) {
+	t.Parallel()
+
+	handler := sampling.New(textHandler(&bytes.Buffer{}), func(context.Context) bool { return false })
+	logger := slog.New(handler)
+
+	depth, highWater, overflowed := sampling.BufferStats(context.Background())
+	assert.Equal(t, 0, depth)
+	assert.Equal(t, 0, highWater)
+	assert.Equal(t, false, overflowed)
+
+	ctx, put := sampling.WithBuffer(context.Background())
+	defer put()
+
+	for range 5 {
+		logger.InfoContext(ctx, "info")
+	}
+	depth, highWater, overflowed = sampling.BufferStats(ctx)
+	assert.Equal(t, 5, depth)
+	assert.Equal(t, 5, highWater)
+	assert.Equal(t, false, overflowed)
+
+	for range 15 {
+		logger.InfoContext(ctx, "info")
+	}
+	logger.ErrorContext(ctx, "error")
+	depth, highWater, overflowed = sampling.BufferStats(ctx)
+	assert.Equal(t, 0, depth)
+	assert.Equal(t, 20, highWater)
+	assert.Equal(t, true, overflowed)
+}
+
+func TestHandler_emptyGroup(t *testing.T) {
+	t.Parallel()
+
+	buf1, buf2 := &bytes.Buffer{}, &bytes.Buffer{}
+	sampler := func(context.Context) bool { return true }
+	attrs := []slog.Attr{slog.String("a", "A")}
+	slog.New(sampling.New(textHandler(buf1), sampler).WithGroup("").WithAttrs(attrs)).Info("msg", "b", "B")
+	slog.New(sampling.New(textHandler(buf2), sampler).WithAttrs(attrs)).Info("msg", "b", "B")
+
+	assert.Equal(t, buf2.String(), buf1.String())
+}
+
+func TestHandler_race(t *testing.T) {
+	t.Parallel()
+
+	procs := runtime.GOMAXPROCS(0)
+	buf := &bytes.Buffer{}
+	handler := sampling.New(textHandler(buf), func(context.Context) bool { return false })
+	logger := slog.New(handler)
+
+	ctx, put := sampling.WithBuffer(context.Background())
+	defer put()
+
+	start := make(chan struct{})
+	var waitGroup sync.WaitGroup
+	waitGroup.Add(procs)
+	for range procs {
+		go func() {
+			defer waitGroup.Done()
+
+			<-start
+			for range 100 {
+				logger.InfoContext(ctx, "info")
+			}
+		}()
+	}
+	close(start)
+	waitGroup.Wait()
+	logger.ErrorContext(ctx, "error")
+
+	assert.Equal(t, procs*100+1, strings.Count(buf.String(), "\n"))
+}
+
+func TestFlush(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		buffered    bool
+		expected    string
+	}{
+		{
+			description: "with buffer",
+			buffered:    true,
+			expected: `level=INFO msg=info
+level=INFO msg=info2
+level=INFO msg=info3
+`,
+		},
+		{
+			description: "without buffer",
+			expected:    "",
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.description, func(t *testing.T) {
+			t.Parallel()
+
+			buf := &bytes.Buffer{}
+			handler := sampling.New(textHandler(buf), func(context.Context) bool { return false })
+			logger := slog.New(handler)
+			ctx := context.Background()
+			if testcase.buffered {
+				var put func()
+				ctx, put = sampling.WithBuffer(ctx)
+				defer put()
+			}
+
+			logger.InfoContext(ctx, "info")
+			logger.InfoContext(ctx, "info2")
+			sampling.Flush(ctx)
+			logger.InfoContext(ctx, "info3")
+			assert.Equal(t, testcase.expected, buf.String())
+		})
+	}
+}
+
+func TestWithOnDiscard(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	handler := sampling.New(textHandler(buf), func(context.Context) bool { return false })
+	logger := slog.New(handler)
+
+	var discarded int
+	ctx, put := sampling.WithBuffer(context.Background(), sampling.WithOnDiscard(func(n int) { discarded += n }))
+	for range 20 {
+		logger.InfoContext(ctx, "info")
+	}
+	put()
+
+	assert.Equal(t, "", buf.String())
+	assert.Equal(t, 20, discarded)
+}
+
+func textHandler(buf *bytes.Buffer) slog.Handler {
+	return slog.NewTextHandler(buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if len(groups) == 0 && attr.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+
+			return attr
+		},
+	})
+}
@@ -3,7 +3,10 @@
 
 package sampling
 
-import "log/slog"
+import (
+	"log/slog"
+	"time"
+)
 
 // WithLevel provides the minimum record level that will be logged without sampling.
 // It discards unsampled records with lower level unless the buffer is activated by Handler.WithBuffer.
@@ -15,8 +18,120 @@ func WithLevel(level slog.Level) Option {
 	}
 }
 
+// WithDrainLevel provides the minimum record level that drains the buffer activated by WithBuffer.
+// Records between the minimum level set by WithLevel and the drain level are logged without draining the buffer.
+//
+// The default drain level is the minimum level set by WithLevel.
+func WithDrainLevel(level slog.Level) Option {
+	return func(options *options) {
+		options.drainLevel = level
+	}
+}
+
+// WithAutoBuffer buffers the last size unsampled records with lower level for each request automatically,
+// without calling WithBuffer at the beginning of the request. The buffer is created on the first record
+// buffered for the context, drained like the buffer created by WithBuffer, and released once the context is done.
+// Unlike the buffer created by WithBuffer, it re-arms after each drain like WithDrainOnce,
+// and it's visible to Flush and BufferStats with the same context.
+//
+// Since the buffer is keyed by the done channel of the context, it requires a cancelable context,
+// e.g. the context of the HTTP request, and contexts derived with cancel or timeout have their own buffers.
+//
+// If the size is <= 0, it does not buffer records automatically.
+func WithAutoBuffer(size int) Option {
+	return func(options *options) {
+		options.autoBuffer = max(size, 0)
+	}
+}
+
+// WithNow provides the function to get the current time, e.g. a fake clock for deterministic testing.
+// It's used to evict records out of the window set by WithBufferWindow.
+//
+// If it is nil, the handler assumes time.Now.
+func WithNow(now func() time.Time) Option {
+	return func(options *options) {
+		options.now = now
+	}
+}
+
 type (
 	// Option configures the Handler with specific options.
 	Option  func(*options)
 	options Handler
 )
+
+// WithBufferSize provides the number of records the buffer could hold before it grows.
+// A larger size avoids growing the buffer for requests which log many records before the error,
+// but the memory is held for every buffered request even if it does not log at all.
+//
+// If the size is <= 0, the buffer assumes 8.
+func WithBufferSize(size int) BufferOption {
+	return func(options *bufferOptions) {
+		options.size = size
+	}
+}
+
+// WithOverflowChunk provides the number of records the buffer grows by each time it's full,
+// e.g. the expected depth of the request, so a burst of records does not reallocate the buffer repeatedly.
+//
+// If the chunk is <= 0, the buffer grows by the size set by WithBufferSize.
+func WithOverflowChunk(chunk int) BufferOption {
+	return func(options *bufferOptions) {
+		options.overflowChunk = chunk
+	}
+}
+
+// WithMaxBuffered provides the maximum number of records the buffer holds.
+// Once the buffer reaches the maximum, it drops the oldest record for each new record,
+// so the memory is bounded and the newest records survive.
+//
+// If the maximum is <= 0, the buffer grows without limit.
+func WithMaxBuffered(maxBuffered int) BufferOption {
+	return func(options *bufferOptions) {
+		options.maxBuffered = maxBuffered
+	}
+}
+
+// WithBufferWindow provides the time window of records the buffer retains,
+// e.g. only the last minute of records for a long-lived streaming RPC.
+// Records older than the window are evicted according to their time
+// while draining or growing the buffer. Records without time are always retained.
+//
+// If the window is <= 0, the buffer retains all records.
+func WithBufferWindow(window time.Duration) BufferOption {
+	return func(options *bufferOptions) {
+		options.window = window
+	}
+}
+
+// WithDrainOnce re-arms the buffer after each drain, so records are buffered again
+// until the next record drains the buffer, e.g. capturing the context around each error burst of the request.
+//
+// By default, records are not buffered anymore for the request once the buffer is drained.
+func WithDrainOnce(drainOnce bool) BufferOption {
+	return func(options *bufferOptions) {
+		options.drainOnce = drainOnce
+	}
+}
+
+// WithOnDiscard provides a function which is called with the number of buffered records
+// discarded without being drained when the buffer is released, e.g. for exporting metrics.
+// It includes records dropped by WithMaxBuffered.
+func WithOnDiscard(onDiscard func(n int)) BufferOption {
+	return func(options *bufferOptions) {
+		options.onDiscard = onDiscard
+	}
+}
+
+type (
+	// BufferOption configures the buffer created by WithBuffer with specific options.
+	BufferOption  func(*bufferOptions)
+	bufferOptions struct {
+		size          int
+		maxBuffered   int
+		overflowChunk int
+		window        time.Duration
+		drainOnce     bool
+		onDiscard     func(n int)
+	}
+)
@@ -0,0 +1,83 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+package sampling
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// And returns a sampler which samples the request only if all the given samplers sample it.
+// The samplers are evaluated in order and short-circuited once one does not sample it,
+// so a sampler with side effects like RateLimited should be the last one.
+//
+// It samples all requests if there is no sampler.
+func And(samplers ...func(ctx context.Context) bool) func(ctx context.Context) bool {
+	return func(ctx context.Context) bool {
+		for _, sampler := range samplers {
+			if !sampler(ctx) {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+// Or returns a sampler which samples the request if any of the given samplers samples it.
+// The samplers are evaluated in order and short-circuited once one samples it.
+//
+// It samples no requests if there is no sampler.
+func Or(samplers ...func(ctx context.Context) bool) func(ctx context.Context) bool {
+	return func(ctx context.Context) bool {
+		for _, sampler := range samplers {
+			if sampler(ctx) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// RateLimited returns a sampler which samples at most n requests in each window of the given duration,
+// e.g. And(probabilistic, RateLimited(10, time.Second)) never exceeds 10 sampled requests per second.
+//
+// It requires the decision cached with Handler.WithCachedDecision, so each request is counted once
+// rather than each record from both Handler.Enabled and Handler.Handle.
+// It samples no requests without the cached decision.
+//
+// If n is <= 0, it samples no requests. If the duration is <= 0, it assumes 1 second.
+func RateLimited(n int, per time.Duration) func(ctx context.Context) bool {
+	if per <= 0 {
+		per = time.Second
+	}
+
+	var (
+		mu      sync.Mutex
+		resetAt time.Time
+		count   int
+	)
+
+	return func(ctx context.Context) bool {
+		if deciding, _ := ctx.Value(decidingKey{}).(bool); !deciding {
+			return false
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if now := time.Now(); !now.Before(resetAt) {
+			resetAt = now.Add(per)
+			count = 0
+		}
+		if count >= n {
+			return false
+		}
+		count++
+
+		return true
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2024 The sloth authors
+// Use of this source code is governed by a MIT license found in the LICENSE file.
+
+package sampling_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/nil-go/sloth/internal/assert"
+	"github.com/nil-go/sloth/sampling"
+)
+
+func TestAnd(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		samplers    []func(context.Context) bool
+		expected    bool
+	}{
+		{
+			description: "no samplers",
+			expected:    true,
+		},
+		{
+			description: "all sampled",
+			samplers:    []func(context.Context) bool{sampled, sampled},
+			expected:    true,
+		},
+		{
+			description: "one unsampled",
+			samplers:    []func(context.Context) bool{sampled, unsampled},
+			expected:    false,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.description, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, testcase.expected, sampling.And(testcase.samplers...)(context.Background()))
+		})
+	}
+}
+
+func TestOr(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		samplers    []func(context.Context) bool
+		expected    bool
+	}{
+		{
+			description: "no samplers",
+			expected:    false,
+		},
+		{
+			description: "one sampled",
+			samplers:    []func(context.Context) bool{unsampled, sampled},
+			expected:    true,
+		},
+		{
+			description: "all unsampled",
+			samplers:    []func(context.Context) bool{unsampled, unsampled},
+			expected:    false,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.description, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, testcase.expected, sampling.Or(testcase.samplers...)(context.Background()))
+		})
+	}
+}
+
+func TestRateLimited(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	handler := sampling.New(textHandler(buf), sampling.RateLimited(3, 50*time.Millisecond))
+	logger := slog.New(handler)
+	for i := range 10 {
+		ctx := handler.WithCachedDecision(context.Background())
+		// Each request is counted once regardless of the number of records.
+		logger.InfoContext(ctx, "info", "request", i)
+		logger.InfoContext(ctx, "info", "request", i)
+	}
+	assert.Equal(t, `level=INFO msg=info request=0
+level=INFO msg=info request=0
+level=INFO msg=info request=1
+level=INFO msg=info request=1
+level=INFO msg=info request=2
+level=INFO msg=info request=2
+`, buf.String())
+
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(t, true, handler.Enabled(handler.WithCachedDecision(context.Background()), slog.LevelInfo))
+	// It does not sample without the cached decision.
+	assert.Equal(t, false, handler.Enabled(context.Background(), slog.LevelInfo))
+}
+
+func TestRateLimited_and(t *testing.T) {
+	t.Parallel()
+
+	// The rate limit only counts requests sampled by the preceding samplers.
+	var calls int
+	handler := sampling.New(slog.NewTextHandler(io.Discard, nil), sampling.And(
+		func(context.Context) bool {
+			calls++
+
+			return calls%2 == 0
+		},
+		sampling.RateLimited(2, time.Hour),
+	))
+	var count int
+	for range 10 {
+		if handler.Enabled(handler.WithCachedDecision(context.Background()), slog.LevelInfo) {
+			count++
+		}
+	}
+	assert.Equal(t, 2, count)
+	assert.Equal(t, 10, calls)
+}
+
+func sampled(context.Context) bool   { return true }
+func unsampled(context.Context) bool { return false }